@@ -0,0 +1,87 @@
+package main
+
+import (
+    "fmt"
+    "os"
+    "os/signal"
+    "time"
+
+    "github.com/ocodo/colorblend/pkg/colorblend"
+)
+
+// animate redraws the buffered blocks in place, advancing the gradient
+// phase by --speed cycles per second until --duration has elapsed, or
+// until interrupted when the duration is zero.
+func animate(out colorblend.Renderer, blocks [][]colorblend.Line) error {
+    if !cfg.Color {
+        for _, block := range blocks {
+            if err := colorblend.PaintLines(&cfg, out, block); err != nil {
+                return err
+            }
+        }
+        return nil
+    }
+
+    height := 0
+    for _, block := range blocks {
+        height += len(block)
+    }
+
+    interrupt := make(chan os.Signal, 1)
+    signal.Notify(interrupt, os.Interrupt)
+    defer signal.Stop(interrupt)
+
+    // Hide the cursor while drawing and make sure it comes back
+    fmt.Fprint(stdout, "\x1b[?25l")
+    defer func() {
+        fmt.Fprint(stdout, "\x1b[?25h")
+        stdout.Flush()
+    }()
+
+    ticker := time.NewTicker(time.Second / time.Duration(fps))
+    defer ticker.Stop()
+
+    start := time.Now()
+loop:
+    for frame := 0; ; frame++ {
+        elapsed := time.Since(start)
+        for _, block := range blocks {
+            p := colorblend.NewPainter(&cfg, out, block)
+            p.Phase += elapsed.Seconds() * speed
+            for _, line := range block {
+                if err := p.PaintLine(line); err != nil {
+                    return err
+                }
+            }
+        }
+
+        if height == 0 {
+            return nil
+        }
+        if frame == 0 {
+            // Saving the cursor at the top of the text once it is on
+            // screen keeps redraws in place even if the first frame
+            // scrolled the terminal.
+            fmt.Fprintf(stdout, "\x1b[%dF\x1b7", height)
+        }
+
+        if err := stdout.Flush(); err != nil {
+            return err
+        }
+
+        if duration > 0 && elapsed >= duration {
+            break
+        }
+
+        select {
+        case <-ticker.C:
+            fmt.Fprint(stdout, "\x1b8")
+        case <-interrupt:
+            break loop
+        }
+    }
+
+    // Leave the cursor below the final frame
+    fmt.Fprintf(stdout, "\x1b8\x1b[%dE", height)
+    return nil
+}
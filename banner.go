@@ -0,0 +1,47 @@
+package main
+
+import (
+    "bufio"
+    "os"
+    "strings"
+
+    "github.com/ocodo/colorblend/pkg/colorblend"
+    "github.com/ocodo/colorblend/pkg/colorblend/figlet"
+    "github.com/spf13/cobra"
+)
+
+var bannerFont string
+
+var bannerCmd = &cobra.Command{
+    Use:   "banner TEXT... [flags]",
+    Short: "Render text in large FIGlet letters and color it",
+    Args:  cobra.MinimumNArgs(1),
+    Run: func(cmd *cobra.Command, args []string) {
+        configure(cmd, nil)
+        font, err := figlet.Load(bannerFont)
+        if err != nil {
+            sourceError("--banner-font", err)
+        }
+
+        cfg.Color = colorEnabled(colorMode)
+        if format != "ansi" {
+            cfg.Color = colorMode != "never"
+        }
+        stdout = bufio.NewWriter(os.Stdout)
+        out, err := colorblend.NewRenderer(format, stdout, &cfg)
+        if err != nil {
+            runtimeError(err)
+        }
+
+        var lines []colorblend.Line
+        for _, row := range font.Render(strings.Join(args, " ")) {
+            lines = append(lines, cfg.ParseLine(row))
+        }
+        if err := colorblend.PaintLines(&cfg, out, lines); err != nil {
+            runtimeError(err)
+        }
+        if err := finish(out); err != nil {
+            runtimeError(err)
+        }
+    },
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+    "bufio"
+    "math"
+    "os"
+    "regexp"
+    "strings"
+
+    "github.com/ocodo/colorblend/pkg/colorblend"
+    "github.com/spf13/cobra"
+)
+
+var (
+    barValue   float64
+    barMax     float64
+    barWidth   int
+    barByValue bool
+)
+
+// barEighths are the blocks filling the last cell of a bar, by eighths.
+var barEighths = []string{"", "▏", "▎", "▍", "▌", "▋", "▊", "▉"}
+
+// barEmpty is the shade standing in for the rest of the bar.
+const barEmpty = "░"
+
+var barCmd = &cobra.Command{
+    Use:   "bar [flags]",
+    Short: "Print a progress bar colored along the gradient",
+    Args:  cobra.NoArgs,
+    Run: func(cmd *cobra.Command, args []string) {
+        // The bar leaves its empty part uncolored with an exclude of its
+        // own and takes its range from --value and --max, so flags doing
+        // the same for input text would be overridden
+        for _, name := range []string{"match", "exclude", "match-progress", "min", "heatmap-field", "heatmap-regex"} {
+            if cmd.Flags().Changed(name) {
+                usageError(cmd, "--%s cannot be used with bar.", name)
+            }
+        }
+        configure(cmd, args)
+        if barWidth < 0 {
+            usageError(cmd, "--width cannot be negative.")
+        }
+        if barMax <= 0 {
+            usageError(cmd, "--max must be greater than 0.")
+        }
+        width := terminalWidth(barWidth)
+        fraction := math.Max(0, math.Min(barValue/barMax, 1))
+
+        // The empty part stays uncolored but still takes up its share of
+        // the gradient, so the filled part shows the colors of where it
+        // has reached
+        cfg.Exclude = regexp.MustCompile(barEmpty + "+")
+        cfg.MatchProgress = "global"
+        if barByValue {
+            cfg.LineProgress = func(colorblend.Line) (float64, bool) {
+                return fraction, true
+            }
+        }
+
+        cfg.Color = colorEnabled(colorMode)
+        if format != "ansi" {
+            cfg.Color = colorMode != "never"
+        }
+        stdout = bufio.NewWriter(os.Stdout)
+        out, err := colorblend.NewRenderer(format, stdout, &cfg)
+        if err != nil {
+            runtimeError(err)
+        }
+
+        line := cfg.ParseLine(barText(fraction, width))
+        if err := colorblend.PaintLines(&cfg, out, []colorblend.Line{line}); err != nil {
+            runtimeError(err)
+        }
+        if err := finish(out); err != nil {
+            runtimeError(err)
+        }
+    },
+}
+
+// barText draws a bar width cells long, filled to fraction to the nearest
+// eighth of a cell.
+func barText(fraction float64, width int) string {
+    eighths := int(math.Round(fraction * float64(width*8)))
+    full, part := eighths/8, eighths%8
+    bar := strings.Repeat("█", full) + barEighths[part]
+    if part > 0 {
+        full++
+    }
+    return bar + strings.Repeat(barEmpty, width-full)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+    "bufio"
+    "fmt"
+    "os"
+    "regexp"
+    "strings"
+)
+
+// base16Entry matches "base0D: "7cafc2"" style lines, quoted or not and
+// with an optional #, at any indentation so both the classic flat layout
+// and the newer nested "palette:" layout are understood.
+var base16Entry = regexp.MustCompile(`^\s*(base[0-9A-Fa-f]{2})\s*:\s*["']?#?([0-9A-Fa-f]{6})["']?\s*(#.*)?$`)
+
+// loadBase16 reads the baseXX colors of a Base16 or Base24 YAML scheme.
+func loadBase16(path string) (map[string]string, error) {
+    f, err := os.Open(path)
+    if err != nil {
+        return nil, err
+    }
+    defer f.Close()
+
+    colors := make(map[string]string)
+    scanner := bufio.NewScanner(f)
+    for scanner.Scan() {
+        if m := base16Entry.FindStringSubmatch(scanner.Text()); m != nil {
+            colors[base16Key(m[1])] = "#" + strings.ToUpper(m[2])
+        }
+    }
+    if err := scanner.Err(); err != nil {
+        return nil, err
+    }
+    if len(colors) == 0 {
+        return nil, fmt.Errorf("%s: no baseXX colors found", path)
+    }
+    return colors, nil
+}
+
+// base16Key normalizes a scheme color name so base0d and base0D match.
+func base16Key(name string) string {
+    return "base" + strings.ToUpper(name[len("base"):])
+}
+
+// resolveSchemeColor replaces a baseXX name with its scheme color, leaving
+// anything else untouched.
+func resolveSchemeColor(color string, scheme map[string]string) string {
+    if len(color) == len("base00") && strings.HasPrefix(strings.ToLower(color), "base") {
+        if hex, ok := scheme[base16Key(color)]; ok {
+            return hex
+        }
+    }
+    return color
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+    "fmt"
+    "io"
+    "os"
+    "runtime"
+    "time"
+    "unicode/utf8"
+
+    "github.com/ocodo/colorblend/pkg/colorblend"
+)
+
+// runBench colors the blocks --bench times, throwing the output away, and
+// reports throughput and allocations on stderr.
+func runBench(blocks [][]colorblend.Line, runs int) error {
+    size, chars := 0, 0
+    for _, block := range blocks {
+        for _, line := range block {
+            s := line.String()
+            size += len(s) + 1
+            chars += utf8.RuneCountInString(s) + 1
+        }
+    }
+
+    var before, after runtime.MemStats
+    runtime.GC()
+    runtime.ReadMemStats(&before)
+    start := time.Now()
+    for i := 0; i < runs; i++ {
+        out, err := colorblend.NewRenderer(format, io.Discard, &cfg)
+        if err != nil {
+            return err
+        }
+        for _, block := range blocks {
+            if err := colorblend.PaintLinesParallel(&cfg, out, block, jobs); err != nil {
+                return err
+            }
+        }
+        if err := out.Flush(); err != nil {
+            return err
+        }
+    }
+    elapsed := time.Since(start)
+    runtime.ReadMemStats(&after)
+
+    seconds := elapsed.Seconds()
+    total := float64(runs)
+    fmt.Fprintf(os.Stderr, "%d runs over %d bytes (%d chars) in %v, %v per run\n", runs, size, chars, elapsed.Round(time.Millisecond), (elapsed / time.Duration(runs)).Round(time.Microsecond))
+    fmt.Fprintf(os.Stderr, "throughput: %.2f MB/s, %.0f chars/s\n", float64(size)*total/seconds/1e6, float64(chars)*total/seconds)
+    fmt.Fprintf(os.Stderr, "allocations: %.0f per run, %.2f MB per run\n", float64(after.Mallocs-before.Mallocs)/total, float64(after.TotalAlloc-before.TotalAlloc)/total/1e6)
+    return nil
+}
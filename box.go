@@ -0,0 +1,65 @@
+package main
+
+import (
+    "strings"
+
+    "github.com/ocodo/colorblend/pkg/colorblend"
+)
+
+// boxBorder holds the characters of one box style: the corners from the
+// top left clockwise, then the horizontal and vertical edges.
+type boxBorder struct {
+    topLeft, topRight, bottomRight, bottomLeft string
+    horizontal, vertical                       string
+}
+
+var boxStyles = map[string]boxBorder{
+    "rounded": {"╭", "╮", "╯", "╰", "─", "│"},
+    "single":  {"┌", "┐", "┘", "└", "─", "│"},
+    "double":  {"╔", "╗", "╝", "╚", "═", "║"},
+    "heavy":   {"┏", "┓", "┛", "┗", "━", "┃"},
+    "ascii":   {"+", "+", "+", "+", "-", "|"},
+}
+
+// boxLines draws a border around lines, padded by padding cells on the
+// left and right and paddingY blank lines above and below. The border is
+// plain text, so the gradient runs over it like the rest of the input.
+func boxLines(lines []colorblend.Line, style string, padding, paddingY int) []colorblend.Line {
+    border := boxStyles[style]
+    if cfg.TabWidth == 0 {
+        // A tab takes up no cells as far as the width goes, so tabs are
+        // expanded to the usual stops every 8 columns to keep the right
+        // border straight
+        tabs := cfg
+        tabs.TabWidth = 8
+        expanded := make([]colorblend.Line, len(lines))
+        for i, line := range lines {
+            expanded[i] = tabs.ParseLine(strings.TrimRight(line.String(), "\r\n"))
+        }
+        lines = expanded
+    }
+    width := 0
+    for _, line := range lines {
+        width = max(width, line.Width())
+    }
+    inner := width + 2*padding
+    side := strings.Repeat(" ", padding)
+
+    boxed := make([]colorblend.Line, 0, len(lines)+2*paddingY+2)
+    boxed = append(boxed, cfg.ParseLine(border.topLeft+strings.Repeat(border.horizontal, inner)+border.topRight))
+    blank := cfg.ParseLine(border.vertical + strings.Repeat(" ", inner) + border.vertical)
+    for i := 0; i < paddingY; i++ {
+        boxed = append(boxed, blank)
+    }
+    for _, line := range lines {
+        // Lines are parsed again with the border, which leaves their
+        // escapes as they were, but not the ending they were read with
+        text := strings.TrimRight(line.String(), "\r\n")
+        fill := strings.Repeat(" ", width-line.Width())
+        boxed = append(boxed, cfg.ParseLine(border.vertical+side+text+fill+side+border.vertical))
+    }
+    for i := 0; i < paddingY; i++ {
+        boxed = append(boxed, blank)
+    }
+    return append(boxed, cfg.ParseLine(border.bottomLeft+strings.Repeat(border.horizontal, inner)+border.bottomRight))
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+    "bufio"
+    "fmt"
+    "os"
+    "path/filepath"
+    "strconv"
+    "strings"
+
+    "github.com/spf13/pflag"
+)
+
+// defaultConfigPath is where the config file is looked for without
+// --config: $XDG_CONFIG_HOME/colorblend/config.toml, falling back to
+// ~/.config/colorblend/config.toml.
+func defaultConfigPath() string {
+    dir := os.Getenv("XDG_CONFIG_HOME")
+    if dir == "" {
+        home, err := os.UserHomeDir()
+        if err != nil {
+            return ""
+        }
+        dir = filepath.Join(home, ".config")
+    }
+    return filepath.Join(dir, "colorblend", "config.toml")
+}
+
+// applyConfigFile sets flags from a config file, leaving alone any flag
+// given on the command line. A missing default config file is fine; a
+// missing --config file is not.
+func applyConfigFile(flags *pflag.FlagSet) error {
+    path := configPath
+    if path == "" {
+        path = defaultConfigPath()
+        if _, err := os.Stat(path); path == "" || os.IsNotExist(err) {
+            return nil
+        }
+    }
+
+    settings, err := loadConfigFile(path)
+    if err != nil {
+        return err
+    }
+    for _, s := range settings {
+        f := flags.Lookup(s.key)
+        if f == nil || s.key == "config" || s.key == "help" || s.key == "version" {
+            return fmt.Errorf("%s:%d: unknown setting %q", path, s.line, s.key)
+        }
+        if f.Changed {
+            continue
+        }
+        if err := flags.Set(s.key, s.value); err != nil {
+            return fmt.Errorf("%s:%d: %s: %v", path, s.line, s.key, err)
+        }
+    }
+    return nil
+}
+
+// configSetting is one key = value line of a config file, with the value
+// already in the form the flag takes on the command line.
+type configSetting struct {
+    key   string
+    value string
+    line  int
+}
+
+// loadConfigFile reads the subset of TOML a config file needs: top-level
+// keys named after long flags, with strings, numbers, booleans and
+// single-line arrays as values. Underscores in keys stand for dashes.
+func loadConfigFile(path string) ([]configSetting, error) {
+    f, err := os.Open(path)
+    if err != nil {
+        return nil, err
+    }
+    defer f.Close()
+
+    var settings []configSetting
+    scanner := bufio.NewScanner(f)
+    for lineNo := 1; scanner.Scan(); lineNo++ {
+        line := strings.TrimSpace(scanner.Text())
+        if line == "" || strings.HasPrefix(line, "#") {
+            continue
+        }
+        if strings.HasPrefix(line, "[") {
+            return nil, fmt.Errorf("%s:%d: tables are not supported, settings go at the top level", path, lineNo)
+        }
+        key, rest, ok := strings.Cut(line, "=")
+        if !ok {
+            return nil, fmt.Errorf("%s:%d: expected key = value", path, lineNo)
+        }
+        key = strings.ReplaceAll(strings.Trim(strings.TrimSpace(key), `"`), "_", "-")
+        value, err := parseConfigValue(strings.TrimSpace(rest))
+        if err != nil {
+            return nil, fmt.Errorf("%s:%d: %v", path, lineNo, err)
+        }
+        settings = append(settings, configSetting{key: key, value: value, line: lineNo})
+    }
+    if err := scanner.Err(); err != nil {
+        return nil, err
+    }
+    return settings, nil
+}
+
+// parseConfigValue turns a TOML value into flag syntax. Arrays become
+// comma separated lists, as --colors takes them.
+func parseConfigValue(s string) (string, error) {
+    if strings.HasPrefix(s, "[") {
+        var items []string
+        s = strings.TrimSpace(s[1:])
+        for !strings.HasPrefix(s, "]") {
+            item, rest, err := nextConfigScalar(s, ",]")
+            if err != nil {
+                return "", err
+            }
+            items = append(items, item)
+            s = strings.TrimSpace(rest)
+            if strings.HasPrefix(s, ",") {
+                s = strings.TrimSpace(s[1:])
+            } else if !strings.HasPrefix(s, "]") {
+                return "", fmt.Errorf("unterminated array")
+            }
+        }
+        if err := checkTrailing(s[1:]); err != nil {
+            return "", err
+        }
+        return strings.Join(items, ","), nil
+    }
+
+    value, rest, err := nextConfigScalar(s, "#")
+    if err != nil {
+        return "", err
+    }
+    return value, checkTrailing(rest)
+}
+
+// nextConfigScalar reads one string, number or boolean from the start of
+// s. Bare values end at any of the stop characters.
+func nextConfigScalar(s, stop string) (value, rest string, err error) {
+    switch {
+    case s == "":
+        return "", "", fmt.Errorf("missing value")
+    case s[0] == '"':
+        for i := 1; i < len(s); i++ {
+            switch s[i] {
+            case '\\':
+                i++
+            case '"':
+                value, err := strconv.Unquote(s[:i+1])
+                if err != nil {
+                    return "", "", fmt.Errorf("invalid string %s", s[:i+1])
+                }
+                return value, s[i+1:], nil
+            }
+        }
+        return "", "", fmt.Errorf("unterminated string")
+    case s[0] == '\'':
+        end := strings.IndexByte(s[1:], '\'')
+        if end < 0 {
+            return "", "", fmt.Errorf("unterminated string")
+        }
+        return s[1 : end+1], s[end+2:], nil
+    }
+    end := strings.IndexAny(s, stop)
+    if end < 0 {
+        end = len(s)
+    }
+    return strings.TrimSpace(s[:end]), s[end:], nil
+}
+
+// checkTrailing allows only a comment after a value.
+func checkTrailing(s string) error {
+    s = strings.TrimSpace(s)
+    if s != "" && !strings.HasPrefix(s, "#") {
+        return fmt.Errorf("unexpected %q after value", s)
+    }
+    return nil
+}
@@ -0,0 +1,11 @@
+//go:build !windows
+
+package main
+
+import "os"
+
+// enableVirtualTerminal has nothing to do outside Windows, where terminals
+// always understand escapes.
+func enableVirtualTerminal(f *os.File) bool {
+    return true
+}
@@ -0,0 +1,25 @@
+//go:build windows
+
+package main
+
+import (
+    "os"
+
+    "golang.org/x/sys/windows"
+)
+
+// enableVirtualTerminal turns on escape sequence processing for a Windows
+// console, so cmd and PowerShell show colors instead of the raw escapes.
+// It reports false for consoles too old to support it, from before
+// Windows 10, which colorblend leaves uncolored.
+func enableVirtualTerminal(f *os.File) bool {
+    handle := windows.Handle(f.Fd())
+    var mode uint32
+    if err := windows.GetConsoleMode(handle, &mode); err != nil {
+        return false
+    }
+    if mode&windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING != 0 {
+        return true
+    }
+    return windows.SetConsoleMode(handle, mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING) == nil
+}
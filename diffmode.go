@@ -0,0 +1,46 @@
+package main
+
+import (
+    "strings"
+
+    "github.com/ocodo/colorblend/pkg/colorblend"
+    "github.com/spf13/cobra"
+)
+
+// diffStops are the gradients of added, removed and context lines in a
+// unified diff. Context has a single dim color.
+var diffStops = map[byte][]string{
+    '+': {"#2EA043", "#7EE787"},
+    '-': {"#DA3633", "#FFA198"},
+    ' ': {"#6E7681"},
+}
+
+// configureDiffMode colors added lines green and removed lines red, each
+// blending like the main gradient, and dims the context. File and hunk
+// headers keep the configured gradient.
+func configureDiffMode(cmd *cobra.Command) {
+    gradients := make(map[byte]*colorblend.Gradient, len(diffStops))
+    for marker, stops := range diffStops {
+        diffCfg := cfg
+        diffCfg.Stops = stops
+        g, err := diffCfg.Gradient()
+        if err != nil {
+            colorError(cmd, "Invalid diff colors: %v", err)
+        }
+        gradients[marker] = g
+    }
+    cfg.LineGradient = func(line colorblend.Line) *colorblend.Gradient {
+        text := line.Text()
+        if text == "" || strings.HasPrefix(text, "+++ ") || strings.HasPrefix(text, "--- ") {
+            return nil
+        }
+        switch text[0] {
+        case '+', '-', ' ':
+            return gradients[text[0]]
+        case '\\':
+            // "\ No newline at end of file"
+            return gradients[' ']
+        }
+        return nil
+    }
+}
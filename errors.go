@@ -0,0 +1,93 @@
+package main
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io/fs"
+    "os"
+    "syscall"
+
+    "github.com/spf13/cobra"
+)
+
+// Exit codes, listed in --help so scripts can tell failures apart.
+const (
+    exitFailure    = 1
+    exitUsage      = 2
+    exitColor      = 3
+    exitIO         = 4
+    exitBrokenPipe = 141 // what a shell reports for a process killed by SIGPIPE
+)
+
+// exitKinds names each exit code in --errors json output.
+var exitKinds = map[int]string{
+    exitFailure:    "failure",
+    exitUsage:      "usage",
+    exitColor:      "color",
+    exitIO:         "io",
+    exitBrokenPipe: "broken-pipe",
+}
+
+// errorFormat is how errors are reported: text, or json for scripts.
+var errorFormat string
+
+// usageError reports flags or arguments that don't make sense, followed
+// by the usage of cmd, and exits.
+func usageError(cmd *cobra.Command, format string, args ...any) {
+    report(cmd, exitUsage, fmt.Sprintf(format, args...))
+}
+
+// colorError reports a color that can't be parsed, followed by the usage
+// of cmd when given, and exits.
+func colorError(cmd *cobra.Command, format string, args ...any) {
+    report(cmd, exitColor, fmt.Sprintf(format, args...))
+}
+
+// runtimeError reports an error met while running and exits with the
+// code matching its cause.
+func runtimeError(err error) {
+    report(nil, errorCode(err, exitFailure), err.Error())
+}
+
+// sourceError reports a palette, scheme or image that couldn't be used
+// for colors. Files that can't be read are I/O errors, the rest color
+// errors.
+func sourceError(flag string, err error) {
+    report(nil, errorCode(err, exitColor), fmt.Sprintf("Invalid %s: %v", flag, err))
+}
+
+// errorCode picks the exit code for err, or fallback when its cause isn't
+// known.
+func errorCode(err error, fallback int) int {
+    var pathErr *fs.PathError
+    switch {
+    case errors.Is(err, syscall.EPIPE):
+        return exitBrokenPipe
+    case errors.As(err, &pathErr):
+        return exitIO
+    }
+    return fallback
+}
+
+func report(cmd *cobra.Command, code int, msg string) {
+    if errorFormat == "json" {
+        data, _ := json.Marshal(struct {
+            Error string `json:"error"`
+            Kind  string `json:"kind"`
+            Code  int    `json:"code"`
+        }{msg, exitKinds[code], code})
+        fmt.Fprintln(os.Stderr, string(data))
+        os.Exit(code)
+    }
+    // Whoever was reading has gone away, so there's no one to tell
+    if code == exitBrokenPipe {
+        os.Exit(code)
+    }
+    fmt.Fprintf(os.Stderr, "Error: %s\n", msg)
+    if cmd != nil {
+        fmt.Fprintln(os.Stderr)
+        cmd.Usage()
+    }
+    os.Exit(code)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+    "os"
+    "slices"
+    "strings"
+
+    "github.com/ocodo/colorblend/pkg/colorblend"
+    "github.com/spf13/cobra"
+    "github.com/spf13/pflag"
+)
+
+// flagAliases maps hidden alias flags to the flag they stand for. pflag
+// gives a flag one shorthand, so extra short forms are separate flags
+// sharing the real flag's value.
+var flagAliases = map[string]string{}
+
+// addAlias adds a hidden flag named alias, with an optional shorthand,
+// that sets the same value as the flag called name.
+func addAlias(flags *pflag.FlagSet, name, alias, shorthand string) {
+    f := flags.Lookup(name)
+    flags.AddFlag(&pflag.Flag{
+        Name:      alias,
+        Shorthand: shorthand,
+        Usage:     "Same as --" + name,
+        Value:     f.Value,
+        DefValue:  f.DefValue,
+        Hidden:    true,
+    })
+    flagAliases[alias] = name
+}
+
+// syncAliases marks a flag as given when one of its aliases was, so the
+// config file and presets don't override it.
+func syncAliases(flags *pflag.FlagSet) {
+    for alias, name := range flagAliases {
+        if a, f := flags.Lookup(alias), flags.Lookup(name); a != nil && f != nil && a.Changed {
+            f.Changed = true
+        }
+    }
+}
+
+// optionalValues recognizes the values of flags whose value is optional,
+// which pflag only takes after =, as in --dither=ordered.
+var optionalValues = map[string]func(arg string) bool{
+    "dither":          func(arg string) bool { return slices.Contains(colorblend.DitherModes, arg) },
+    "skip-whitespace": func(arg string) bool { return arg == "color" || arg == "progress" },
+    "box":             func(arg string) bool { _, ok := boxStyles[arg]; return ok },
+    "random":          func(arg string) bool { return slices.Contains(randomKinds, arg) },
+    "cubehelix": func(arg string) bool {
+        key, _, _ := strings.Cut(arg, "=")
+        return strings.Contains(arg, "=") && slices.Contains([]string{"start", "rot", "hue", "gamma"}, key)
+    },
+}
+
+// checkOptionalValues exits with a usage error when a flag with an
+// optional value was given one after a space, which would otherwise be
+// opened as an input file. Files that exist by that name are left alone.
+func checkOptionalValues(cmd *cobra.Command, args []string) {
+    for name, isValue := range optionalValues {
+        f := cmd.Flags().Lookup(name)
+        if f == nil || !f.Changed || f.Value.String() != f.NoOptDefVal {
+            continue
+        }
+        for _, arg := range args {
+            if _, err := os.Stat(arg); err != nil && isValue(arg) {
+                usageError(cmd, "--%s takes its value after =, as in --%s=%s.", name, name, arg)
+            }
+        }
+    }
+}
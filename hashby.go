@@ -0,0 +1,40 @@
+package main
+
+import (
+    "hash/fnv"
+    "math"
+    "regexp"
+
+    "github.com/ocodo/colorblend/pkg/colorblend"
+)
+
+// hashProgress colors every line matching re at the point of the gradient
+// its key hashes to, so the same key gets the same color on every run.
+// The key is the first capture group that matched, or the whole match.
+// Lines without a match keep the gradient.
+func hashProgress(re *regexp.Regexp) func(line colorblend.Line) (float64, bool) {
+    return func(line colorblend.Line) (float64, bool) {
+        match := re.FindStringSubmatch(line.String())
+        if match == nil {
+            return 0, false
+        }
+        key := match[0]
+        for _, group := range match[1:] {
+            if group != "" {
+                key = group
+                break
+            }
+        }
+        h := fnv.New64a()
+        h.Write([]byte(key))
+        // FNV leaves keys differing only at the end close together, so
+        // mix the bits before using them as a position
+        sum := h.Sum64()
+        sum ^= sum >> 33
+        sum *= 0xff51afd7ed558ccd
+        sum ^= sum >> 33
+        sum *= 0xc4ceb9fe1a85ec53
+        sum ^= sum >> 33
+        return float64(sum) / math.MaxUint64, true
+    }
+}
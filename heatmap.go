@@ -0,0 +1,82 @@
+package main
+
+import (
+    "math"
+    "regexp"
+    "strconv"
+    "strings"
+
+    "github.com/ocodo/colorblend/pkg/colorblend"
+)
+
+// heatmapNumber finds a number with an optional size suffix, as printed
+// by du -h or ls -lh.
+var heatmapNumber = regexp.MustCompile(`[-+]?(?:\d+\.?\d*|\.\d+)(?:[eE][-+]?\d+)?([KMGTP]i?B?)?`)
+
+// heatmapMatcher is the compiled --heatmap-regex.
+var heatmapMatcher *regexp.Regexp
+
+// lineValue finds the number a line is colored by: the --heatmap-field
+// whitespace separated field, counting from 1, or the first group (or
+// whole match) of --heatmap-regex.
+func lineValue(line colorblend.Line) (float64, bool) {
+    text := line.String()
+    if heatmapMatcher != nil {
+        match := heatmapMatcher.FindStringSubmatch(text)
+        if match == nil {
+            return 0, false
+        }
+        text = match[0]
+        if len(match) > 1 {
+            text = match[1]
+        }
+    } else {
+        fields := strings.Fields(text)
+        if heatmapField > len(fields) {
+            return 0, false
+        }
+        text = fields[heatmapField-1]
+    }
+
+    match := heatmapNumber.FindStringSubmatch(text)
+    if match == nil {
+        return 0, false
+    }
+    value, err := strconv.ParseFloat(strings.TrimSuffix(match[0], match[1]), 64)
+    if err != nil {
+        return 0, false
+    }
+    if match[1] != "" {
+        value *= math.Pow(1024, float64(strings.IndexByte("KMGTP", match[1][0])+1))
+    }
+    return value, true
+}
+
+// valueRange finds the smallest and largest values in blocks, for the
+// ends of --min and --max that weren't given.
+func valueRange(blocks [][]colorblend.Line) (low, high float64) {
+    low, high = math.Inf(1), math.Inf(-1)
+    for _, block := range blocks {
+        for _, line := range block {
+            if v, ok := lineValue(line); ok {
+                low, high = math.Min(low, v), math.Max(high, v)
+            }
+        }
+    }
+    return low, high
+}
+
+// heatmapProgress colors every line with a value at its place between low
+// and high, clamped to the ends. Lines without one keep the gradient.
+func heatmapProgress(low, high float64) func(line colorblend.Line) (float64, bool) {
+    return func(line colorblend.Line) (float64, bool) {
+        v, ok := lineValue(line)
+        if !ok {
+            return 0, false
+        }
+        if high <= low {
+            return 0, true
+        }
+        return math.Max(0, math.Min(1, (v-low)/(high-low))), true
+    }
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+    "fmt"
+    "image"
+    _ "image/gif"
+    _ "image/jpeg"
+    _ "image/png"
+    "os"
+    "sort"
+    "strings"
+
+    "github.com/lucasb-eyer/go-colorful"
+)
+
+// maxImageSamples bounds how many pixels are considered so large photos
+// stay fast; the image is sampled on an even grid.
+const maxImageSamples = 1 << 16
+
+// paletteFromImage extracts n dominant colors from a PNG, JPEG or GIF
+// using median cut, ordered from darkest to lightest.
+func paletteFromImage(path string, n int) ([]string, error) {
+    f, err := os.Open(path)
+    if err != nil {
+        return nil, err
+    }
+    defer f.Close()
+    img, _, err := image.Decode(f)
+    if err != nil {
+        return nil, fmt.Errorf("%s: %w", path, err)
+    }
+
+    bounds := img.Bounds()
+    stride := 1
+    for (bounds.Dx()/stride)*(bounds.Dy()/stride) > maxImageSamples {
+        stride++
+    }
+    var pixels [][3]uint8
+    for y := bounds.Min.Y; y < bounds.Max.Y; y += stride {
+        for x := bounds.Min.X; x < bounds.Max.X; x += stride {
+            r, g, b, a := img.At(x, y).RGBA()
+            // Mostly transparent pixels don't contribute to the look
+            if a < 0x8000 {
+                continue
+            }
+            pixels = append(pixels, [3]uint8{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8)})
+        }
+    }
+    if len(pixels) == 0 {
+        return nil, fmt.Errorf("%s: image has no opaque pixels", path)
+    }
+
+    boxes := medianCut(pixels, n)
+    colors := make([]colorful.Color, len(boxes))
+    for i, box := range boxes {
+        colors[i] = averageColor(box)
+    }
+    sort.Slice(colors, func(i, j int) bool {
+        li, _, _ := colors[i].Lab()
+        lj, _, _ := colors[j].Lab()
+        return li < lj
+    })
+
+    stops := make([]string, len(colors))
+    for i, c := range colors {
+        stops[i] = strings.ToUpper(c.Hex())
+    }
+    return stops, nil
+}
+
+// medianCut repeatedly splits the box with the widest channel range at its
+// median until there are n boxes or no box can be split further.
+func medianCut(pixels [][3]uint8, n int) [][][3]uint8 {
+    boxes := [][][3]uint8{pixels}
+    for len(boxes) < n {
+        best, bestChannel, bestRange := -1, 0, 0
+        for i, box := range boxes {
+            if len(box) < 2 {
+                continue
+            }
+            channel, width := widestChannel(box)
+            if width > bestRange {
+                best, bestChannel, bestRange = i, channel, width
+            }
+        }
+        if best < 0 {
+            break
+        }
+        box := boxes[best]
+        sort.Slice(box, func(i, j int) bool { return box[i][bestChannel] < box[j][bestChannel] })
+        mid := len(box) / 2
+        boxes[best] = box[:mid]
+        boxes = append(boxes, box[mid:])
+    }
+    return boxes
+}
+
+func widestChannel(box [][3]uint8) (channel, width int) {
+    for c := 0; c < 3; c++ {
+        lo, hi := 255, 0
+        for _, p := range box {
+            lo = min(lo, int(p[c]))
+            hi = max(hi, int(p[c]))
+        }
+        if hi-lo > width {
+            channel, width = c, hi-lo
+        }
+    }
+    return channel, width
+}
+
+func averageColor(box [][3]uint8) colorful.Color {
+    var sum [3]float64
+    for _, p := range box {
+        for c := range sum {
+            sum[c] += float64(p[c])
+        }
+    }
+    n := float64(len(box)) * 255
+    return colorful.Color{R: sum[0] / n, G: sum[1] / n, B: sum[2] / n}
+}
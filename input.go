@@ -0,0 +1,248 @@
+package main
+
+import (
+    "bufio"
+    "bytes"
+    "fmt"
+    "io"
+    "math"
+    "os"
+    "strings"
+    "time"
+
+    "github.com/ocodo/colorblend/pkg/colorblend"
+)
+
+// readLines reads r to EOF and parses every line into segments, applying
+// the input preprocessing flags.
+func readLines(r io.Reader) ([]colorblend.Line, error) {
+    var lines []colorblend.Line
+    err := scanLines(r, func(line colorblend.Line) error {
+        lines = append(lines, line)
+        return nil
+    })
+    return lines, err
+}
+
+// scanLines calls fn with each preprocessed line of r as soon as it has
+// been read, stopping at the first error fn returns.
+func scanLines(r io.Reader, fn func(colorblend.Line) error) error {
+    if jsonPretty {
+        pretty := prettyJSON(r)
+        defer pretty.Close()
+        r = pretty
+    }
+    scanner := bufio.NewScanner(r)
+    scanner.Buffer(make([]byte, 64*1024), math.MaxInt)
+    scanner.Split(scanLineEndings)
+    for scanner.Scan() {
+        // The line keeps its ending so the output ends the same way,
+        // including a last line without one
+        raw, ending := scanner.Text(), ""
+        for _, e := range lineEndings {
+            if strings.HasSuffix(raw, e) {
+                raw, ending = raw[:len(raw)-len(e)], e
+                break
+            }
+        }
+        if err := fn(cfg.ParseLineEnding(raw, ending)); err != nil {
+            return err
+        }
+    }
+    return scanner.Err()
+}
+
+// lineEndings are the line endings kept in the output, longest first.
+var lineEndings = []string{"\r\n", "\n", "\r"}
+
+// scanLineEndings is a bufio.SplitFunc for lines ended by \n, \r\n or a
+// lone \r, returning each line with its ending.
+func scanLineEndings(data []byte, atEOF bool) (int, []byte, error) {
+    i := bytes.IndexAny(data, "\r\n")
+    switch {
+    case i < 0:
+        if atEOF && len(data) > 0 {
+            return len(data), data, nil
+        }
+        return 0, nil, nil
+    case data[i] == '\n':
+        return i + 1, data[:i+1], nil
+    case i+1 < len(data):
+        if data[i+1] == '\n' {
+            return i + 2, data[:i+2], nil
+        }
+        return i + 1, data[:i+1], nil
+    case atEOF:
+        return len(data), data, nil
+    }
+    // A \r at the end of the buffer may be the start of \r\n
+    return 0, nil, nil
+}
+
+// inputFile holds the parsed lines of one named input.
+type inputFile struct {
+    name  string
+    lines []colorblend.Line
+}
+
+// readInputs reads every named file in order, with "-" or no names at all
+// meaning standard input.
+func readInputs(names []string) ([]inputFile, error) {
+    if len(names) == 0 {
+        names = []string{"-"}
+    }
+    files := make([]inputFile, 0, len(names))
+    for _, name := range names {
+        lines, err := readInput(name)
+        if err != nil {
+            return nil, err
+        }
+        files = append(files, inputFile{name: name, lines: lines})
+    }
+    return files, nil
+}
+
+// fileHeader builds head -v style header lines. Every header after the
+// first is preceded by a blank line.
+func fileHeader(name string, first bool) []colorblend.Line {
+    if name == "-" {
+        name = "standard input"
+    }
+    header := []colorblend.Line{cfg.ParseLine("==> " + name + " <==")}
+    if !first {
+        header = append([]colorblend.Line{nil}, header...)
+    }
+    return header
+}
+
+func readInput(name string) ([]colorblend.Line, error) {
+    var lines []colorblend.Line
+    err := streamInput(name, func(line colorblend.Line) error {
+        lines = append(lines, line)
+        return nil
+    })
+    return lines, err
+}
+
+// streamInput calls fn with each line of the named input as it arrives.
+func streamInput(name string, fn func(colorblend.Line) error) error {
+    if name == "-" {
+        if err := scanLines(os.Stdin, fn); err != nil {
+            return fmt.Errorf("reading stdin: %w", err)
+        }
+        return nil
+    }
+    f, err := os.Open(name)
+    if err != nil {
+        return err
+    }
+    defer f.Close()
+    var r io.Reader = f
+    if follow {
+        r = followReader{f}
+    }
+    if err := scanLines(r, fn); err != nil {
+        return fmt.Errorf("reading %s: %w", name, err)
+    }
+    return nil
+}
+
+// followInterval is how often a followed file is polled for new data.
+const followInterval = 250 * time.Millisecond
+
+// followReader turns EOF into a wait for more data, like tail -f.
+type followReader struct {
+    r io.Reader
+}
+
+func (f followReader) Read(p []byte) (int, error) {
+    for {
+        n, err := f.r.Read(p)
+        if n > 0 || err != io.EOF {
+            return n, err
+        }
+        time.Sleep(followInterval)
+    }
+}
+
+// streamInputs colors each input line by line as it is read, writing
+// every line out as soon as it is colored. The gradient position carries
+// over between files unless --scope file restarts it.
+func streamInputs(out colorblend.Renderer, names []string) error {
+    if len(names) == 0 {
+        names = []string{"-"}
+    }
+    var totals []int
+    if twoPass {
+        var err error
+        if totals, err = countInputs(names); err != nil {
+            return err
+        }
+    }
+
+    var p *colorblend.Painter
+    paint := func(line colorblend.Line) error {
+        if err := p.PaintLine(line); err != nil {
+            return err
+        }
+        return stdout.Flush()
+    }
+    for i, name := range names {
+        if p == nil || scope == "file" {
+            p = colorblend.NewPainter(&cfg, out, nil)
+            switch {
+            case totals != nil && scope == "file":
+                p.SetTotal(totals[i])
+            case totals != nil:
+                p.SetTotal(sum(totals))
+            case totalUnits > 0:
+                p.SetTotal(totalUnits)
+            }
+        }
+        if showHeaders {
+            for _, line := range fileHeader(name, i == 0) {
+                if err := paint(line); err != nil {
+                    return err
+                }
+            }
+        }
+        if err := streamInput(name, paint); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// countInputs reads every named file once to measure it in gradient
+// units, so the files can then be colored as they are read a second
+// time. Headers are counted with their file.
+func countInputs(names []string) ([]int, error) {
+    totals := make([]int, len(names))
+    for i, name := range names {
+        count := func(line colorblend.Line) error {
+            if cfg.Direction == "horizontal" {
+                totals[i] += cfg.Cells(line)
+            } else {
+                totals[i]++
+            }
+            return nil
+        }
+        if showHeaders {
+            for _, line := range fileHeader(name, i == 0) {
+                count(line)
+            }
+        }
+        if err := streamInput(name, count); err != nil {
+            return nil, err
+        }
+    }
+    return totals, nil
+}
+
+func sum(values []int) int {
+    total := 0
+    for _, v := range values {
+        total += v
+    }
+    return total
+}
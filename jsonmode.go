@@ -0,0 +1,35 @@
+package main
+
+import (
+    "bytes"
+    "encoding/json"
+    "io"
+)
+
+// prettyJSON indents the JSON values read from r, one after another as
+// each is complete, so a stream of JSON lines can still be followed.
+// Closing the reader stops the decoding.
+func prettyJSON(r io.Reader) *io.PipeReader {
+    pr, pw := io.Pipe()
+    go func() {
+        dec := json.NewDecoder(r)
+        for {
+            var value json.RawMessage
+            if err := dec.Decode(&value); err != nil {
+                if err == io.EOF {
+                    err = nil
+                }
+                pw.CloseWithError(err)
+                return
+            }
+            var b bytes.Buffer
+            // The decoder has checked the value, so indenting can't fail
+            json.Indent(&b, value, "", "  ")
+            b.WriteByte('\n')
+            if _, err := pw.Write(b.Bytes()); err != nil {
+                return
+            }
+        }
+    }()
+    return pr
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+    "sort"
+
+    "github.com/ocodo/colorblend/pkg/colorblend"
+    "github.com/ocodo/colorblend/pkg/colorblend/figlet"
+)
+
+// listKinds names what --list can print, in the order they are documented.
+var listKinds = []string{"colorspaces", "presets", "colormaps", "formats", "easings", "interpolations", "hue-directions", "depths", "dither-modes", "themes", "banner-fonts"}
+
+// listValues returns the names of every option of one kind.
+func listValues(kind string) ([]string, error) {
+    switch kind {
+    case "colorspaces":
+        return colorblend.Colorspaces, nil
+    case "presets":
+        names, err := userPresetNames()
+        if err != nil {
+            return nil, err
+        }
+        return append(presetNames(), names...), nil
+    case "colormaps":
+        return sortedKeys(colorblend.Colormaps), nil
+    case "formats":
+        return colorblend.Formats(), nil
+    case "easings":
+        return colorblend.Easings, nil
+    case "interpolations":
+        return colorblend.Interpolations, nil
+    case "hue-directions":
+        return colorblend.HueDirections, nil
+    case "depths":
+        return colorblend.Depths, nil
+    case "dither-modes":
+        return colorblend.DitherModes, nil
+    case "themes":
+        return sortedKeys(colorblend.Themes), nil
+    case "banner-fonts":
+        return figlet.Fonts(), nil
+    }
+    return nil, fmt.Errorf("unknown list: %s", kind)
+}
+
+// printList prints the options of one kind, a name per line or as a JSON
+// array with --json.
+func printList(kind string, asJSON bool) error {
+    values, err := listValues(kind)
+    if err != nil {
+        return err
+    }
+    if asJSON {
+        data, err := json.Marshal(values)
+        if err != nil {
+            return err
+        }
+        fmt.Println(string(data))
+        return nil
+    }
+    for _, v := range values {
+        fmt.Println(v)
+    }
+    return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+    keys := make([]string, 0, len(m))
+    for k := range m {
+        keys = append(keys, k)
+    }
+    sort.Strings(keys)
+    return keys
+}
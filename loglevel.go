@@ -0,0 +1,71 @@
+package main
+
+import (
+    "regexp"
+
+    "github.com/ocodo/colorblend/pkg/colorblend"
+    "github.com/spf13/cobra"
+)
+
+// logLevelToken finds the severity of a log line: the first upper case
+// level name on it.
+var logLevelToken = regexp.MustCompile(`\b(FATAL|CRIT|CRITICAL|ERR|ERROR|WARN|WARNING|INFO|DEBUG|TRACE)\b`)
+
+// logLevels maps level names onto the severities that get a gradient.
+var logLevels = map[string]string{
+    "FATAL":    "error",
+    "CRIT":     "error",
+    "CRITICAL": "error",
+    "ERR":      "error",
+    "ERROR":    "error",
+    "WARN":     "warn",
+    "WARNING":  "warn",
+    "INFO":     "info",
+    "DEBUG":    "debug",
+    "TRACE":    "debug",
+}
+
+// levelStops are the gradients of each severity, set by --error-colors,
+// --warn-colors, --info-colors and --debug-colors.
+var levelStops = map[string]*[]string{
+    "error": new([]string),
+    "warn":  new([]string),
+    "info":  new([]string),
+    "debug": new([]string),
+}
+
+// defaultLevelStops are the flag defaults of levelStops.
+var defaultLevelStops = map[string][]string{
+    "error": {"#FF3B30", "#FF9500"},
+    "warn":  {"#FFCC00", "#FF9500"},
+    "info":  {"#34C759", "#5AC8FA"},
+    "debug": {"#8E8E93", "#C7C7CC"},
+}
+
+// configureLogLevels gives every line with a log level the gradient of
+// its severity. Lines without one keep the configured gradient.
+func configureLogLevels(cmd *cobra.Command) {
+    // Each severity blends like the main gradient, only through its own
+    // stops
+    gradients := make(map[string]*colorblend.Gradient, len(levelStops))
+    for level, stops := range levelStops {
+        *stops = colorblend.JoinColorArgs(*stops)
+        for i, stop := range *stops {
+            (*stops)[i] = normalizeStop(cmd, "--"+level+"-colors", stop)
+        }
+        levelCfg := cfg
+        levelCfg.Stops = *stops
+        g, err := levelCfg.Gradient()
+        if err != nil {
+            colorError(cmd, "Invalid --%s-colors: %v", level, err)
+        }
+        gradients[level] = g
+    }
+    cfg.LineGradient = func(line colorblend.Line) *colorblend.Gradient {
+        token := logLevelToken.FindString(line.String())
+        if token == "" {
+            return nil
+        }
+        return gradients[logLevels[token]]
+    }
+}
@@ -3,236 +3,1001 @@ package main
 import (
     "bufio"
     "fmt"
-    "io"
     "math"
+    "math/rand"
     "os"
+    "os/signal"
+    "regexp"
+    "runtime"
+    "slices"
     "strings"
+    "syscall"
+    "time"
 
-    "github.com/lucasb-eyer/go-colorful"
+    "github.com/ocodo/colorblend/pkg/colorblend"
+    "github.com/ocodo/colorblend/pkg/colorblend/figlet"
     "github.com/spf13/cobra"
+    "github.com/spf13/pflag"
+    "golang.org/x/term"
 )
 
 var osExit = os.Exit
 
-func blendHCLWithDirection(c1, c2 colorful.Color, t float64, hueDirection string) colorful.Color {
-    h1, c1Chroma, l1 := c1.Hcl()
-    h2, c2Chroma, l2 := c2.Hcl()
+var (
+    cfg               = colorblend.DefaultConfig()
+    startColor        string
+    endColor          string
+    colorMode         string
+    scope             string
+    showHeaders       bool
+    stream            bool
+    follow            bool
+    animated          bool
+    fps               int
+    speed             float64
+    duration          time.Duration
+    freq              float64
+    spread            float64
+    seed              int64
+    presetName        string
+    listPresetsFlag   bool
+    paletteFile       string
+    base16File        string
+    imageFile         string
+    imageColors       int
+    cubehelixSpec     string
+    colorStops        []string
+    easing            string
+    randomKind        string
+    format            string
+    output            string
+    benchRuns         int
+    jobs              int
+    twoPass           bool
+    totalUnits        int
+    configPath        string
+    listKind          string
+    listJSON          bool
+    matchPattern      string
+    excludePattern    string
+    logLevelMode      bool
+    hashPattern       string
+    progressByTime    bool
+    timeRegex         string
+    timeFormat        string
+    heatmapField      int
+    heatmapRegex      string
+    heatmapMin        float64
+    heatmapMax        float64
+    csvMode           bool
+    tsvMode           bool
+    jsonPretty        bool
+    diffMode          bool
+    detectBackground  bool
+    boxStyle          string
+    boxPadding        int
+    boxPaddingY       int
+)
+
+// stdout batches everything written to standard output, so lines reach
+// the terminal in a few large writes instead of one per character.
+var stdout *bufio.Writer
+
+// colorEnabled decides whether escapes should be emitted. An explicit
+// --color=always/never wins, otherwise NO_COLOR disables color,
+// CLICOLOR_FORCE enables it, and finally stdout must be a terminal, one
+// that handles escapes on Windows.
+func colorEnabled(mode string) bool {
+    switch mode {
+    case "always":
+        enableVirtualTerminal(os.Stdout)
+        return true
+    case "never":
+        return false
+    }
+    if os.Getenv("NO_COLOR") != "" {
+        return false
+    }
+    if force := os.Getenv("CLICOLOR_FORCE"); force != "" && force != "0" {
+        enableVirtualTerminal(os.Stdout)
+        return true
+    }
+    return term.IsTerminal(int(os.Stdout.Fd())) && enableVirtualTerminal(os.Stdout)
+}
+
+var rootCmd = &cobra.Command{
+    Use:   "colorblend [file...]",
+    Short: "Applies a color gradient to text",
+    Args:  cobra.ArbitraryArgs,
+    Run:   apply,
+}
 
-    h1 = math.Mod(h1+360, 360)
-    h2 = math.Mod(h2+360, 360)
+// configure checks the flags and settles cfg from them, exiting with a
+// usage error when they don't fit together.
+func configure(cmd *cobra.Command, args []string) {
+    if errorFormat != "text" && errorFormat != "json" {
+        format := errorFormat
+        errorFormat = "text"
+        usageError(cmd, "Invalid value for --errors: %s. Must be 'text' or 'json'.", format)
+    }
 
-    var deltaH float64
-    switch hueDirection {
-    case "shortest", "short", "sh":
-        deltaH = h2 - h1
-        if deltaH > 180 {
-            deltaH -= 360
-        } else if deltaH < -180 {
-            deltaH += 360
+    // Command line flags win over a saved preset, which wins over
+    // the config file. The config file may name a preset too.
+    for _, load := range []func(*pflag.FlagSet) error{applyUserPreset, applyConfigFile, applyUserPreset} {
+        if err := load(cmd.Flags()); err != nil {
+            runtimeError(fmt.Errorf("Invalid config file or preset: %w", err))
         }
-    case "clockwise", "cw":
-        deltaH = math.Mod(h2-h1+360, 360)
-    case "counter-clockwise", "counterclockwise", "ccw":
-        deltaH = -math.Mod(h1-h2+360, 360)
-    default:
-        deltaH = h2 - h1
-        if deltaH > 180 {
-            deltaH -= 360
-        } else if deltaH < -180 {
-            deltaH += 360
+    }
+    checkOptionalValues(cmd, args)
+
+    if base16File != "" {
+        scheme, err := loadBase16(base16File)
+        if err != nil {
+            sourceError("--base16 scheme", err)
         }
+        startColor = resolveSchemeColor(startColor, scheme)
+        endColor = resolveSchemeColor(endColor, scheme)
     }
 
-    h := math.Mod(h1+t*deltaH+360, 360)
-    c := c1Chroma + t*(c2Chroma-c1Chroma)
-    l := l1 + t*(l2-l1)
+    // Validate colors, writing them all as hex from here on
+    startColor = normalizeColor(cmd, "--start-color", startColor)
+    endColor = normalizeColor(cmd, "--end-color", endColor)
 
-    return colorful.Hcl(h, c, l)
-}
+    switch cfg.Direction {
+    case "h":
+        cfg.Direction = "horizontal"
+    case "v":
+        cfg.Direction = "vertical"
+    }
+    if cfg.Direction != "horizontal" && cfg.Direction != "vertical" {
+        usageError(cmd, "Invalid value for --gradient-direction: %s. Must be 'horizontal' or 'vertical'.", cfg.Direction)
+    }
 
-func getGradientColor(progress float64, startHex, endHex, hueDirection string) (string, error) {
-    startColor, err := colorful.Hex(startHex)
-    if err != nil {
-        return "", fmt.Errorf("invalid start hex color: %s (%w)", startHex, err)
+    if !slices.Contains(colorblend.Colorspaces, cfg.Colorspace) {
+        usageError(cmd, "Invalid value for --colorspace: %s. Must be one of: %s.", cfg.Colorspace, strings.Join(colorblend.Colorspaces, ", "))
+    }
+
+    if !slices.Contains(colorblend.HueDirections, cfg.HueDirection) {
+        usageError(cmd, "Invalid value for --hue-direction: %s. Must be 'shortest', 'longest', 'clockwise' or 'counter-clockwise'.", cfg.HueDirection)
+    }
+
+    if !slices.Contains(colorblend.Interpolations, cfg.Interpolation) {
+        usageError(cmd, "Invalid value for --interpolation: %s. Must be one of: %s.", cfg.Interpolation, strings.Join(colorblend.Interpolations, ", "))
     }
-    endColor, err := colorful.Hex(endHex)
+
+    colorStops = colorblend.JoinColorArgs(colorStops)
+    for i, stop := range colorStops {
+        colorStops[i] = normalizeStop(cmd, "--colors", stop)
+    }
+
+    ease, err := colorblend.ParseEasing(easing)
     if err != nil {
-        return "", fmt.Errorf("invalid end hex color: %s (%w)", endHex, err)
+        usageError(cmd, "Invalid value for --easing: %s. %v.", easing, err)
     }
+    cfg.Easing = ease
 
-    // Interpolate in HCL with directional hue
-    interpolated := blendHCLWithDirection(startColor, endColor, progress, hueDirection)
-    r, g, b := interpolated.Clamped().RGB255()
-    return fmt.Sprintf("38;2;%d;%d;%dm", r, g, b), nil
-}
+    if !slices.Contains(colorblend.Depths, cfg.Depth) {
+        usageError(cmd, "Invalid value for --depth: %s. Must be one of: %s.", cfg.Depth, strings.Join(colorblend.Depths, ", "))
+    }
 
-var (
-    startColor        string
-    endColor          string
-    gradientDirection string
-    hueDirection      string
-    steps             int
-    invert            bool
-)
+    if !slices.Contains(colorblend.DitherModes, cfg.Dither) {
+        usageError(cmd, "Invalid value for --dither: %s. Must be one of: %s.", cfg.Dither, strings.Join(colorblend.DitherModes, ", "))
+    }
 
-var rootCmd = &cobra.Command{
-    Use:   "colorblend",
-    Short: "Applies a color gradient to text",
-    Run: func(cmd *cobra.Command, args []string) {
-        // Validate colors
-        if _, err := colorful.Hex(startColor); err != nil {
-            fmt.Fprintf(os.Stderr, "Error: Invalid format for --start-color: %s. Must be a 7-character hex string (e.g., #RRGGBB). Details: %v\n\n", startColor, err)
-            cmd.Usage()
-            os.Exit(1)
+    if cfg.SkipWhitespace != "" && cfg.SkipWhitespace != "color" && cfg.SkipWhitespace != "progress" {
+        usageError(cmd, "Invalid value for --skip-whitespace: %s. Must be one of: color, progress.", cfg.SkipWhitespace)
+    }
+
+    if matchPattern != "" {
+        re, err := regexp.Compile(matchPattern)
+        if err != nil {
+            usageError(cmd, "Invalid --match regular expression: %v", err)
         }
-        if _, err := colorful.Hex(endColor); err != nil {
-            fmt.Fprintf(os.Stderr, "Error: Invalid format for --end-color: %s. Must be a 7-character hex string (e.g., #RRGGBB). Details: %v\n\n", endColor, err)
-            cmd.Usage()
-            os.Exit(1)
+        cfg.Match = re
+    }
+    if excludePattern != "" {
+        re, err := regexp.Compile(excludePattern)
+        if err != nil {
+            usageError(cmd, "Invalid --exclude regular expression: %v", err)
         }
-
-        if gradientDirection != "horizontal" && gradientDirection != "vertical" && gradientDirection != "h" && gradientDirection != "v" {
-            fmt.Fprintf(os.Stderr, "Error: Invalid value for --gradient-direction: %s. Must be 'horizontal' or 'vertical'.\n\n", gradientDirection)
-            cmd.Usage()
-            os.Exit(1)
+        cfg.Exclude = re
+    }
+    if hashPattern != "" {
+        re, err := regexp.Compile(hashPattern)
+        if err != nil {
+            usageError(cmd, "Invalid --hash-by regular expression: %v", err)
         }
+        cfg.LineProgress = hashProgress(re)
+    }
 
-        if steps < 0 {
-            fmt.Fprintf(os.Stderr, "Error: --steps cannot be negative.\n\n")
-            cmd.Usage()
-            os.Exit(1)
+    if progressByTime {
+        if hashPattern != "" {
+            usageError(cmd, "--progress-by-time and --hash-by cannot be combined.")
+        }
+        // The earliest and latest timestamps must be known up front
+        if stream || follow || twoPass || totalUnits > 0 || cfg.PerLine || cfg.Period > 0 {
+            usageError(cmd, "--progress-by-time cannot be combined with --stream, --follow, --two-pass, --total-chars, --per-line or --period.")
+        }
+        re, err := regexp.Compile(timeRegex)
+        if err != nil {
+            usageError(cmd, "Invalid --time-regex regular expression: %v", err)
         }
+        timeMatcher = re
+    }
 
-        if len(args) > 0 {
-            fmt.Fprintf(os.Stderr, "Error: Unexpected arguments: %s\n\n", strings.Join(args, " "))
-            cmd.Usage()
-            os.Exit(1)
+    if csvMode || tsvMode {
+        if csvMode && tsvMode {
+            usageError(cmd, "--csv and --tsv cannot be combined.")
+        }
+        if cfg.Match != nil || cfg.Exclude != nil {
+            usageError(cmd, "--csv and --tsv cannot be combined with --match or --exclude.")
+        }
+        if tsvMode && cfg.TabWidth > 0 {
+            usageError(cmd, "--tsv needs the tabs between fields, so it cannot be combined with --tabs.")
         }
+        if !slices.Contains(colorblend.FieldProgresses, cfg.FieldProgress) {
+            usageError(cmd, "Invalid value for --field-progress: %s. Must be one of: %s.", cfg.FieldProgress, strings.Join(colorblend.FieldProgresses, ", "))
+        }
+        cfg.FieldSeparator = ","
+        if tsvMode {
+            cfg.FieldSeparator = "\t"
+        }
+    }
 
-        // Read input lines
-        reader := bufio.NewReader(os.Stdin)
-        var lines [][]rune
-        for {
-            lineBytes, _, err := reader.ReadLine()
+    if jsonPretty {
+        cfg.JSON = true
+    }
+    if cfg.JSON && cfg.Markdown {
+        usageError(cmd, "--json-mode and --markdown cannot be combined.")
+    }
+    if cfg.JSON && (cfg.Match != nil || cfg.Exclude != nil || cfg.FieldSeparator != "") {
+        usageError(cmd, "--json-mode cannot be combined with --match, --exclude, --csv or --tsv.")
+    }
+
+    heatmap := heatmapField != 0 || heatmapRegex != ""
+    if heatmapField < 0 {
+        usageError(cmd, "--heatmap-field counts from 1.")
+    }
+    if heatmap {
+        if heatmapField != 0 && heatmapRegex != "" {
+            usageError(cmd, "--heatmap-field and --heatmap-regex cannot be combined.")
+        }
+        if hashPattern != "" || progressByTime {
+            usageError(cmd, "--heatmap-field and --heatmap-regex cannot be combined with --hash-by or --progress-by-time.")
+        }
+        if heatmapRegex != "" {
+            re, err := regexp.Compile(heatmapRegex)
             if err != nil {
-                if err == io.EOF {
-                    break
-                }
-                fmt.Fprintf(os.Stderr, "Error reading stdin: %v\n", err)
-                os.Exit(1)
+                usageError(cmd, "Invalid --heatmap-regex regular expression: %v", err)
             }
-            lines = append(lines, []rune(string(lineBytes)))
+            heatmapMatcher = re
+        }
+        // Without both ends of the range the whole input has to be read
+        // to find them, so it can only be colored as it streams in with
+        // both given
+        if cmd.Flags().Changed("min") && cmd.Flags().Changed("max") {
+            cfg.LineProgress = heatmapProgress(heatmapMin, heatmapMax)
+        } else if stream || follow || twoPass || totalUnits > 0 || cfg.PerLine || cfg.Period > 0 {
+            usageError(cmd, "--heatmap-field and --heatmap-regex need --min and --max to be combined with --stream, --follow, --two-pass, --total-chars, --per-line or --period.")
         }
+    } else if cmd.Name() != "bar" && (cmd.Flags().Changed("min") || cmd.Flags().Changed("max")) {
+        usageError(cmd, "--min and --max require --heatmap-field or --heatmap-regex.")
+    }
+    if !slices.Contains(colorblend.MatchProgresses, cfg.MatchProgress) {
+        usageError(cmd, "Invalid value for --match-progress: %s. Must be one of: %s.", cfg.MatchProgress, strings.Join(colorblend.MatchProgresses, ", "))
+    }
 
-        if len(lines) == 0 {
-            fmt.Printf("\x1b[0m\n")
-            return
+    if !slices.Contains(colorblend.FinalResets, cfg.FinalReset) {
+        usageError(cmd, "Invalid value for --final-reset: %s. Must be one of: %s.", cfg.FinalReset, strings.Join(colorblend.FinalResets, ", "))
+    }
+    // Holding the last line ending back for the reset would delay each
+    // streamed line's ending until the next one, and upset the cursor
+    // moves of an animation
+    if cfg.FinalReset == "end" && (animated || ((stream || follow) && !cmd.Flags().Changed("final-reset"))) {
+        cfg.FinalReset = "line"
+    }
+
+    if cfg.Fade != "" && !slices.Contains(colorblend.Fades, cfg.Fade) {
+        usageError(cmd, "Invalid value for --fade: %s. Must be one of: %s.", cfg.Fade, strings.Join(colorblend.Fades, ", "))
+    }
+    // Fading down the lines needs to know how many there are
+    if cfg.Fade != "" && cfg.Direction == "vertical" && (stream || follow) && !twoPass && totalUnits == 0 {
+        usageError(cmd, "--fade with a vertical gradient cannot be combined with --stream or --follow unless --two-pass or --total-chars gives the size.")
+    }
+
+    if cfg.Simulate != "" && !slices.Contains(colorblend.Simulations, cfg.Simulate) {
+        usageError(cmd, "Invalid value for --simulate: %s. Must be one of: %s.", cfg.Simulate, strings.Join(colorblend.Simulations, ", "))
+    }
+
+    if cfg.Dither != "none" && cfg.Depth == "truecolor" {
+        usageError(cmd, "--dither requires --depth 256 or 16.")
+    }
+
+    if cfg.Gamma < 0 {
+        usageError(cmd, "--gamma cannot be negative.")
+    }
+
+    if cfg.Saturate < 0 {
+        usageError(cmd, "--saturate cannot be negative.")
+    }
+
+    if cfg.Brighten < -1 || cfg.Brighten > 1 {
+        usageError(cmd, "--brighten must be between -1 and 1.")
+    }
+
+    if cfg.Contrast < 0 {
+        usageError(cmd, "--contrast cannot be negative.")
+    }
+
+    if cfg.Jitter < 0 || cfg.Jitter > 1 {
+        usageError(cmd, "--jitter must be between 0 and 1.")
+    }
+
+    if colorMode != "auto" && colorMode != "always" && colorMode != "never" {
+        usageError(cmd, "Invalid value for --color: %s. Must be 'auto', 'always' or 'never'.", colorMode)
+    }
+
+    if cfg.BlendExisting < 0 || cfg.BlendExisting > 1 {
+        usageError(cmd, "--blend-existing must be between 0 and 1.")
+    }
+
+    if scope != "all" && scope != "file" {
+        usageError(cmd, "Invalid value for --scope: %s. Must be 'all' or 'file'.", scope)
+    }
+
+    if cfg.TabWidth < 0 {
+        usageError(cmd, "--tabs cannot be negative.")
+    }
+
+    if cfg.Period < 0 {
+        usageError(cmd, "--period cannot be negative.")
+    }
+
+    if cfg.PerLine && cfg.Direction == "vertical" {
+        usageError(cmd, "--per-line only applies to horizontal gradients.")
+    }
+
+    // Knowing the size up front lets a gradient span a stream
+    sized := twoPass || totalUnits > 0
+
+    if (stream || follow) && cfg.Direction == "vertical" && cfg.Period == 0 && !sized {
+        usageError(cmd, "--stream and --follow with a vertical gradient require --period.")
+    }
+
+    // A streamed horizontal gradient can't span the whole input
+    if (stream || follow) && cfg.Direction == "horizontal" && cfg.Period == 0 && !sized {
+        cfg.PerLine = true
+    }
+
+    if !slices.Contains(colorblend.Formats(), format) {
+        usageError(cmd, "Invalid value for --format: %s. Must be one of: %s.", format, strings.Join(colorblend.Formats(), ", "))
+    }
+
+    if _, ok := colorblend.Themes[cfg.Theme]; !ok {
+        usageError(cmd, "Invalid value for --theme: %s. Must be 'dark' or 'light'.", cfg.Theme)
+    }
+
+    if cfg.IRCColors != 16 && cfg.IRCColors != 99 {
+        usageError(cmd, "--irc-colors must be 16 or 99.")
+    }
+
+    if cfg.Background != "" {
+        cfg.Background = normalizeColor(cmd, "--background", cfg.Background)
+    }
+
+    if cfg.MinLuminance < 0 || cfg.MinLuminance > 100 || cfg.MaxLuminance < 0 || cfg.MaxLuminance > 100 {
+        usageError(cmd, "--min-luminance and --max-luminance must be between 0 and 100.")
+    }
+    if cfg.MinLuminance > cfg.MaxLuminance {
+        usageError(cmd, "--min-luminance cannot be above --max-luminance.")
+    }
+
+    if cfg.MinContrast != 0 && (cfg.MinContrast < 1 || cfg.MinContrast > 21) {
+        usageError(cmd, "--min-contrast must be between 1 and 21.")
+    }
+
+    if format != "ansi" && (animated || cfg.Depth != "truecolor") {
+        usageError(cmd, "--animate and --depth only apply to --format ansi.")
+    }
+    if format != "ansi" && (cfg.Bold || cfg.Italic || cfg.Underline || cfg.Dim || cfg.Reverse) {
+        usageError(cmd, "--bold, --italic, --underline, --dim and --reverse only apply to --format ansi.")
+    }
+    if !slices.Contains(colorblend.Targets, cfg.Target) {
+        usageError(cmd, "Invalid value for --target: %s. Must be one of: %s.", cfg.Target, strings.Join(colorblend.Targets, ", "))
+    }
+    if format != "ansi" && cfg.Target != "foreground" {
+        usageError(cmd, "--target only applies to --format ansi.")
+    }
+
+    if sized || totalUnits < 0 {
+        if totalUnits < 0 {
+            usageError(cmd, "--total-chars cannot be negative.")
+        }
+        if twoPass && totalUnits > 0 {
+            usageError(cmd, "--two-pass and --total-chars cannot be combined.")
         }
+        if animated || follow || cfg.PerLine || cfg.Period > 0 {
+            usageError(cmd, "--two-pass and --total-chars cannot be combined with --animate, --follow, --per-line or --period.")
+        }
+        if twoPass && (len(args) == 0 || slices.Contains(args, "-")) {
+            usageError(cmd, "--two-pass reads its input twice, so it needs files rather than standard input.")
+        }
+    }
 
-        var totalGradientUnits int
-        if gradientDirection == "horizontal" {
-            for _, line := range lines {
-                totalGradientUnits += len(line)
-            }
-            if totalGradientUnits == 0 {
-                for range lines {
-                    fmt.Printf("\x1b[0m\n")
-                }
-                return
-            }
-        } else {
-            totalGradientUnits = len(lines)
-        }
-
-        charCountHorizontal := 0
-
-        for lineIndex, line := range lines {
-            if gradientDirection == "vertical" && len(line) == 0 && totalGradientUnits > 1 {
-                progress := 0.0
-                if totalGradientUnits > 1 {
-                    progress = float64(lineIndex) / float64(totalGradientUnits-1)
-                }
-                if invert {
-                    progress = 1.0 - progress
-                }
-                if steps > 0 {
-                    progress = math.Round(progress*float64(steps)) / float64(steps)
-                }
-
-                colorPart, err := getGradientColor(progress, startColor, endColor, hueDirection)
-                if err != nil {
-                    fmt.Fprintf(os.Stderr, "Error getting gradient color for empty line: %v\n", err)
-                    os.Exit(1)
-                }
-
-                fmt.Printf("\x1b[%s\n", colorPart)
-                continue
-            }
+    if animated && (stream || follow) {
+        usageError(cmd, "--animate cannot be combined with --stream or --follow.")
+    }
 
-            for _, char := range line {
-                progress := 0.0
-                if gradientDirection == "horizontal" {
-                    if totalGradientUnits > 1 {
-                        progress = float64(charCountHorizontal) / float64(totalGradientUnits-1)
-                    }
-                    charCountHorizontal++
-                } else {
-                    if totalGradientUnits > 1 {
-                        progress = float64(lineIndex) / float64(totalGradientUnits-1)
-                    }
-                }
-
-                if invert {
-                    progress = 1.0 - progress
-                }
-                if steps > 0 {
-                    progress = math.Round(progress*float64(steps)) / float64(steps)
-                }
-
-                colorPart, err := getGradientColor(progress, startColor, endColor, hueDirection)
-                if err != nil {
-                    fmt.Fprintf(os.Stderr, "Error getting gradient color: %v\n", err)
-                    os.Exit(1)
-                }
-
-                fmt.Printf("\x1b[%s%c", colorPart, char)
-            }
-            if !(gradientDirection == "vertical" && len(line) == 0 && totalGradientUnits > 1) {
-                fmt.Printf("\n")
+    if boxStyle != "" {
+        if _, ok := boxStyles[boxStyle]; !ok {
+            usageError(cmd, "Invalid value for --box: %s. Must be one of: %s.", boxStyle, strings.Join(sortedKeys(boxStyles), ", "))
+        }
+        if boxPadding < 0 || boxPaddingY < 0 {
+            usageError(cmd, "--box-padding and --box-padding-y cannot be negative.")
+        }
+        // The box is as wide as the widest line, so all of it is read first
+        if stream || follow || sized {
+            usageError(cmd, "--box cannot be combined with --stream, --follow, --two-pass or --total-chars.")
+        }
+    }
+
+    if benchRuns < 0 {
+        usageError(cmd, "--bench cannot be negative.")
+    }
+
+    if benchRuns > 0 && (animated || follow) {
+        usageError(cmd, "--bench cannot be combined with --animate or --follow.")
+    }
+
+    if jobs < 0 {
+        usageError(cmd, "--jobs cannot be negative.")
+    }
+    if jobs == 0 {
+        jobs = runtime.NumCPU()
+    }
+
+    if fps <= 0 {
+        usageError(cmd, "--fps must be positive.")
+    }
+
+    if freq <= 0 || spread <= 0 {
+        usageError(cmd, "--freq and --spread must be positive.")
+    }
+
+    if presetName != "" {
+        if _, ok := presets[presetName]; !ok {
+            usageError(cmd, "Unknown --preset: %s. Must be one of: %s.", presetName, strings.Join(presetNames(), ", "))
+        }
+    }
+
+    if cfg.Colormap != "" {
+        if _, ok := colorblend.Colormaps[cfg.Colormap]; !ok {
+            usageError(cmd, "Unknown --colormap: %s. Must be one of: viridis, magma, inferno, plasma, turbo.", cfg.Colormap)
+        }
+    }
+
+    if cubehelixSpec != "" {
+        params, err := colorblend.ParseCubehelix(cubehelixSpec)
+        if err != nil {
+            usageError(cmd, "Invalid --cubehelix: %v", err)
+        }
+        cfg.Cubehelix = &params
+    }
+
+    if randomKind != "" && !slices.Contains(randomKinds, randomKind) {
+        usageError(cmd, "Invalid value for --random: %s. Must be one of: %s.", randomKind, strings.Join(randomKinds, ", "))
+    }
+
+    sources := 0
+    for _, set := range []bool{randomKind != "", cfg.Rainbow, len(colorStops) > 0, presetName != "", paletteFile != "", imageFile != "", cfg.Colormap != "", cubehelixSpec != ""} {
+        if set {
+            sources++
+        }
+    }
+    if sources > 1 {
+        usageError(cmd, "Only one of --random, --rainbow, --colors, --preset, --palette-file, --from-image, --colormap and --cubehelix may be given.")
+    }
+
+    if imageColors < 1 {
+        usageError(cmd, "--image-colors must be at least 1.")
+    }
+
+    if cfg.Steps < 0 {
+        usageError(cmd, "--steps cannot be negative.")
+    }
+
+    cfg.Stops = []string{startColor, endColor}
+    if randomKind != "" {
+        cfg.Stops = randomStops(randomKind, seededRand())
+    }
+    if len(colorStops) > 0 {
+        cfg.Stops = colorStops
+    }
+    if presetName != "" {
+        cfg.Stops = presets[presetName]
+    }
+    if paletteFile != "" {
+        stops, err := loadPaletteFile(paletteFile)
+        if err != nil {
+            sourceError("--palette-file", err)
+        }
+        cfg.Stops = stops
+    }
+    if imageFile != "" {
+        stops, err := paletteFromImage(imageFile, imageColors)
+        if err != nil {
+            sourceError("--from-image", err)
+        }
+        cfg.Stops = stops
+    }
+    if _, _, err := colorblend.ParseStopPositions(cfg.Stops); err != nil {
+        colorError(cmd, "Invalid --colors: %v", err)
+    }
+
+    // lolcat advances its rainbow by freq radians every spread
+    // characters and by one spread per line, starting seed spreads in.
+    if cmd.Flags().Changed("freq") || cmd.Flags().Changed("spread") {
+        cfg.Period = 2 * math.Pi * spread / freq
+        cfg.LineShift = spread
+        cfg.PerLine = cfg.Direction == "horizontal"
+        s := seed
+        if s == 0 {
+            s = rand.Int63n(256)
+        }
+        cfg.Offset += float64(s) * freq / (2 * math.Pi)
+    }
+
+    if logLevelMode && diffMode {
+        usageError(cmd, "--loglevel-mode and --diff-mode cannot be combined.")
+    }
+    if logLevelMode {
+        configureLogLevels(cmd)
+    }
+    if diffMode {
+        configureDiffMode(cmd)
+    }
+
+    if cfg.Jitter > 0 {
+        cfg.Rand = seededRand()
+    }
+}
+
+// apply colors the input files, or standard input, with the gradient
+// configured by the flags. It is both the default action and the apply
+// subcommand.
+func apply(cmd *cobra.Command, args []string) {
+    if listKind != "" {
+        if !slices.Contains(listKinds, listKind) {
+            usageError(cmd, "Invalid value for --list: %s. Must be one of: %s.", listKind, strings.Join(listKinds, ", "))
+        }
+        if err := printList(listKind, listJSON); err != nil {
+            runtimeError(err)
+        }
+        return
+    }
+
+    configure(cmd, args)
+    // Following waits on its file forever, so any after it would never
+    // be read
+    if follow && len(args) > 1 {
+        usageError(cmd, "--follow takes a single input file.")
+    }
+    if listPresetsFlag {
+        listPresets()
+        return
+    }
+
+    if output != "" {
+        f, err := os.Create(output)
+        if err != nil {
+            runtimeError(err)
+        }
+        defer f.Close()
+        os.Stdout = f
+    }
+    if format == "png" && term.IsTerminal(int(os.Stdout.Fd())) {
+        usageError(cmd, "Refusing to write PNG data to a terminal; use -o FILE or redirect stdout.")
+    }
+
+    stdout = bufio.NewWriter(os.Stdout)
+
+    cfg.Color = colorEnabled(colorMode)
+    if format != "ansi" {
+        // Markup isn't bound for a terminal, so only --color never
+        // turns it off
+        cfg.Color = colorMode != "never"
+    }
+    if detectBackground && cfg.Color && format == "ansi" {
+        adaptToTerminal(cmd)
+    }
+
+    out, err := colorblend.NewRenderer(format, stdout, &cfg)
+    if err != nil {
+        runtimeError(err)
+    }
+
+    // Per-line and periodic gradients don't depend on the size of the
+    // input, nor do inputs whose size was given, so lines can be colored
+    // as soon as they arrive.
+    sized := twoPass || totalUnits > 0
+    if !animated && benchRuns == 0 && boxStyle == "" && (stream || follow || sized || cfg.PerLine || cfg.Period > 0) {
+        if err := streamInputs(out, args); err != nil {
+            runtimeError(err)
+        }
+        if err := finish(out); err != nil {
+            runtimeError(err)
+        }
+        return
+    }
+
+    // Read input lines
+    files, err := readInputs(args)
+    if err != nil {
+        runtimeError(err)
+    }
+
+    blocks := make([][]colorblend.Line, len(files))
+    for i, file := range files {
+        blocks[i] = file.lines
+        if showHeaders {
+            blocks[i] = append(fileHeader(file.name, i == 0), file.lines...)
+        }
+    }
+
+    if scope == "all" {
+        var lines []colorblend.Line
+        for _, block := range blocks {
+            lines = append(lines, block...)
+        }
+        blocks = [][]colorblend.Line{lines}
+    }
+
+    if boxStyle != "" {
+        for i, block := range blocks {
+            blocks[i] = boxLines(block, boxStyle, boxPadding, boxPaddingY)
+        }
+    }
+
+    if progressByTime {
+        cfg.LineProgress = timeProgress(blocks)
+    }
+    if (heatmapField != 0 || heatmapRegex != "") && cfg.LineProgress == nil {
+        low, high := valueRange(blocks)
+        if cmd.Flags().Changed("min") {
+            low = heatmapMin
+        }
+        if cmd.Flags().Changed("max") {
+            high = heatmapMax
+        }
+        cfg.LineProgress = heatmapProgress(low, high)
+    }
+
+    if benchRuns > 0 {
+        // Output is discarded, so color whatever stdout is
+        cfg.Color = colorMode != "never"
+        if err := runBench(blocks, benchRuns); err != nil {
+            runtimeError(err)
+        }
+        return
+    }
+
+    if animated {
+        err = animate(out, blocks)
+    } else {
+        for _, block := range blocks {
+            if err = colorblend.PaintLinesParallel(&cfg, out, block, jobs); err != nil {
+                break
             }
         }
+    }
+    if err != nil {
+        runtimeError(err)
+    }
+
+    if err := finish(out); err != nil {
+        runtimeError(err)
+    }
+}
 
-        fmt.Printf("\x1b[0m\n")
-    },
+// normalizeColor checks the color given to flag, in any form
+// colorblend.ParseColor accepts, and returns it as hex.
+func normalizeColor(cmd *cobra.Command, flag, color string) string {
+    hex, err := colorblend.NormalizeColor(color)
+    if err != nil {
+        colorError(cmd, "Invalid color for %s: %s. Must be hex (e.g., #RRGGBB or #RGB), rgb(), hsl() or hsv(). Details: %v", flag, color, err)
+    }
+    return hex
+}
+
+// normalizeStop is normalizeColor for a gradient stop, which may end with
+// its position.
+func normalizeStop(cmd *cobra.Command, flag, stop string) string {
+    color, at, found := strings.Cut(stop, "@")
+    if !found {
+        return normalizeColor(cmd, flag, stop)
+    }
+    return normalizeColor(cmd, flag, color) + "@" + at
+}
+
+// finish ends the rendered output and writes out whatever is still
+// buffered.
+func finish(out colorblend.Renderer) error {
+    if err := out.Flush(); err != nil {
+        return err
+    }
+    return stdout.Flush()
 }
 
 func init() {
-    rootCmd.Flags().StringVarP(&startColor, "start-color", "s", "#FF00FF", "Starting HEX color (e.g., #FF00FF for magenta)")
-    rootCmd.Flags().StringVarP(&endColor, "end-color", "e", "#00FFFF", "Ending HEX color (e.g., #00FFFF for cyan)")
-    rootCmd.Flags().StringVarP(&gradientDirection, "gradient-direction", "g", "horizontal", "Direction of the gradient (horizontal, vertical or h, v)")
-    rootCmd.Flags().StringVarP(&hueDirection, "color-direction", "c", "shortest", "Direction for color hue interpolation (shortest, clockwise, counter-clockwise or sh, cw, ccw)")
-    rootCmd.Flags().IntVarP(&steps, "steps", "t", 0, "Number of discrete color steps (0 for smooth gradient)")
-    rootCmd.Flags().BoolVarP(&invert, "invert", "i", false, "Invert the gradient direction")
+    // --hue-direction is accepted as another name for --color-direction
+    rootCmd.Flags().SetNormalizeFunc(func(f *pflag.FlagSet, name string) pflag.NormalizedName {
+        if name == "hue-direction" {
+            name = "color-direction"
+        }
+        return pflag.NormalizedName(name)
+    })
+    rootCmd.Flags().StringVarP(&startColor, "start-color", "s", "#FF00FF", "Starting color, as hex or rgb(), hsl() or hsv() (e.g., #FF00FF for magenta)")
+    rootCmd.Flags().StringVarP(&endColor, "end-color", "e", "#00FFFF", "Ending color, as hex or rgb(), hsl() or hsv() (e.g., #00FFFF for cyan)")
+    rootCmd.Flags().StringVarP(&cfg.Direction, "gradient-direction", "g", "horizontal", "Direction of the gradient (horizontal, vertical or h, v); also -d")
+    rootCmd.Flags().StringVarP(&cfg.HueDirection, "color-direction", "c", "shortest", "Direction for hue interpolation in hcl, hsv, hsl, oklch and hsluv (shortest, longest, clockwise, counter-clockwise or sh, lg, cw, ccw); also --hue-direction")
+    rootCmd.Flags().StringSliceVar(&colorStops, "colors", nil, "Comma separated gradient stops, replacing --start-color and --end-color; a stop may be placed with @ and 0 to 1 or a percentage")
+    rootCmd.Flags().StringVar(&cfg.Interpolation, "interpolation", "linear", "How the gradient passes through three or more stops (linear, bezier, spline)")
+    rootCmd.Flags().StringVar(&cfg.Colorspace, "colorspace", "hcl", "Colorspace to interpolate in ("+strings.Join(colorblend.Colorspaces, ", ")+")")
+    rootCmd.Flags().Float64Var(&cfg.Gamma, "gamma", 0, "Power-law gamma for the linear-rgb colorspace (0 uses the sRGB transfer curve)")
+    rootCmd.Flags().Float64Var(&cfg.Saturate, "saturate", 1, "Multiply the chroma of every gradient color (0 is gray, 1 unchanged)")
+    rootCmd.Flags().StringVar(&cfg.Target, "target", "foreground", "What the gradient colors: the text, or an underline beneath it with SGR 58 ("+strings.Join(colorblend.Targets, ", ")+")")
+    rootCmd.Flags().BoolVar(&cfg.Bold, "bold", false, "Make the colored text bold")
+    rootCmd.Flags().BoolVar(&cfg.Italic, "italic", false, "Make the colored text italic")
+    rootCmd.Flags().BoolVar(&cfg.Underline, "underline", false, "Underline the colored text")
+    rootCmd.Flags().BoolVar(&cfg.Dim, "dim", false, "Make the colored text dim (faint)")
+    rootCmd.Flags().BoolVar(&cfg.Reverse, "reverse", false, "Swap the foreground and background of the colored text, so the gradient fills the background")
+    rootCmd.Flags().StringVar(&cfg.Fade, "fade", "", "Fade the text in from the background, out into it, or both, along each line (down the lines when vertical) ("+strings.Join(colorblend.Fades, ", ")+")")
+    rootCmd.Flags().StringVar(&cfg.Simulate, "simulate", "", "Show the colors as seen with a color vision deficiency ("+strings.Join(colorblend.Simulations, ", ")+")")
+    rootCmd.Flags().Float64Var(&cfg.Brighten, "brighten", 0, "Shift the lightness of every gradient color (-1 to 1)")
+    rootCmd.Flags().Float64Var(&cfg.Contrast, "contrast", 1, "Stretch every gradient color away from mid gray (1 unchanged)")
+    rootCmd.Flags().Float64Var(&cfg.Jitter, "jitter", 0, "Randomly perturb each character's color in Lab space by up to this amount (0 to 1)")
+    rootCmd.Flags().StringVar(&easing, "easing", "linear", "Easing applied to gradient progress ("+strings.Join(colorblend.Easings, ", ")+" or cubic-bezier(x1,y1,x2,y2))")
+    rootCmd.Flags().IntVarP(&cfg.Steps, "steps", "t", 0, "Number of discrete color steps (0 for smooth gradient); also -n")
+    rootCmd.Flags().BoolVarP(&cfg.Invert, "invert", "i", false, "Invert the gradient direction")
+    rootCmd.Flags().StringVar(&cfg.Depth, "depth", "truecolor", "Output color depth (truecolor, 256, 16)")
+    rootCmd.Flags().StringVar(&cfg.Dither, "dither", "none", "Dither reduced color depths along the text, diffusion when given no mode; a mode goes after =, as in --dither=ordered (none, ordered, diffusion)")
+    rootCmd.Flags().Lookup("dither").NoOptDefVal = "diffusion"
+    rootCmd.Flags().StringVar(&cfg.FinalReset, "final-reset", "end", "Where to reset colors: once at the end before the last line ending, at the end of every colored line, or none (end, line, none)")
+    rootCmd.Flags().StringVar(&format, "format", "ansi", "Output format ("+strings.Join(colorblend.Formats(), ", ")+")")
+    rootCmd.Flags().IntVar(&cfg.IRCColors, "irc-colors", 99, "mIRC palette size for --format irc (16, or 99 for clients with the extended colors)")
+    rootCmd.Flags().BoolVar(&cfg.LatexPreamble, "latex-preamble", false, "Wrap --format latex output in a minimal standalone document")
+    rootCmd.Flags().StringVarP(&output, "output", "o", "", "Write output to a file instead of stdout")
+    rootCmd.Flags().StringVar(&cfg.Background, "background", "", "Background color for page and image formats and --min-contrast (default from --theme)")
+    rootCmd.Flags().BoolVar(&detectBackground, "detect-background", false, "Ask the terminal for its colors, then match --theme and --background to them and keep the gradient readable on them")
+    rootCmd.Flags().Float64Var(&cfg.MinLuminance, "min-luminance", 0, "Lighten colors to at least this CIE L* lightness (0 to 100)")
+    rootCmd.Flags().Float64Var(&cfg.MaxLuminance, "max-luminance", 100, "Darken colors to at most this CIE L* lightness (0 to 100)")
+    rootCmd.Flags().Float64Var(&cfg.MinContrast, "min-contrast", 0, "Lighten or darken colors to at least this WCAG contrast ratio with the background (1 to 21, 0 off)")
+    rootCmd.Flags().StringVar(&cfg.Theme, "theme", "dark", "Page theme for uncolored text and the default background (dark, light)")
+    rootCmd.Flags().StringVar(&cfg.Font, "font", colorblend.DefaultFont, "Font family for page and image formats")
+    rootCmd.Flags().StringVar(&colorMode, "color", "auto", "When to emit color (auto, always, never); auto honors NO_COLOR, CLICOLOR_FORCE and whether stdout is a terminal")
+    rootCmd.Flags().StringVar(&cfg.SkipWhitespace, "skip-whitespace", "", "Leave spaces and tabs uncolored; =progress also stops them advancing the gradient (color, progress)")
+    rootCmd.Flags().Lookup("skip-whitespace").NoOptDefVal = "color"
+    rootCmd.Flags().BoolVar(&cfg.IgnoreIndent, "ignore-indent", false, "Start the gradient of each line at its first non-space character, leaving the indentation uncolored")
+    rootCmd.Flags().StringVar(&matchPattern, "match", "", "Only color the parts of each line matching this regular expression")
+    rootCmd.Flags().StringVar(&excludePattern, "exclude", "", "Leave the parts of each line matching this regular expression uncolored")
+    rootCmd.Flags().StringVar(&cfg.MatchProgress, "match-progress", "match", "How --match and --exclude lay out the gradient: across each colored stretch, or across the whole text (match, global)")
+    rootCmd.Flags().BoolVar(&csvMode, "csv", false, "Treat lines as comma separated fields, coloring by column and leaving the commas uncolored")
+    rootCmd.Flags().BoolVar(&tsvMode, "tsv", false, "Treat lines as tab separated fields, coloring by column and leaving the tabs uncolored")
+    rootCmd.Flags().StringVar(&cfg.FieldProgress, "field-progress", "column", "How --csv and --tsv lay out the gradient: one color per column, or across each field (column, field)")
+    rootCmd.Flags().BoolVar(&cfg.JSON, "json-mode", false, "Lay the gradient over the keys of JSON input, with values colored by type and punctuation left plain")
+    rootCmd.Flags().BoolVar(&jsonPretty, "json-pretty", false, "Indent JSON input before coloring it (implies --json-mode)")
+    rootCmd.Flags().BoolVar(&cfg.Markdown, "markdown", false, "Treat input as Markdown: headings each span the gradient, markup stays plain and code is shown in one color")
+    rootCmd.Flags().BoolVar(&diffMode, "diff-mode", false, "Color unified diffs: added lines green, removed lines red and context dimmed")
+    rootCmd.Flags().StringVar(&boxStyle, "box", "", "Draw a box around the input, its border following the gradient; a style goes after =, as in --box=double ("+strings.Join(sortedKeys(boxStyles), ", ")+")")
+    rootCmd.Flags().Lookup("box").NoOptDefVal = "rounded"
+    rootCmd.Flags().IntVar(&boxPadding, "box-padding", 1, "Spaces between the text and the left and right of the --box border")
+    rootCmd.Flags().IntVar(&boxPaddingY, "box-padding-y", 0, "Blank lines between the text and the top and bottom of the --box border")
+    rootCmd.Flags().StringVar(&hashPattern, "hash-by", "", "Color each line matching this regular expression by a hash of the match (or its first group), so the same key always gets the same color")
+    rootCmd.Flags().BoolVar(&progressByTime, "progress-by-time", false, "Color each line by its timestamp, from the earliest to the latest, instead of by its position")
+    rootCmd.Flags().StringVar(&timeRegex, "time-regex", defaultTimePattern, "Regular expression finding the timestamp of a line for --progress-by-time (its first group, if any)")
+    rootCmd.Flags().StringVar(&timeFormat, "time-format", "", "Go time layout of the --time-regex timestamps, or unix for epoch seconds (default ISO 8601)")
+    rootCmd.Flags().IntVar(&heatmapField, "heatmap-field", 0, "Color each line by the number in this whitespace separated field, counting from 1")
+    rootCmd.Flags().StringVar(&heatmapRegex, "heatmap-regex", "", "Color each line by the number this regular expression (or its first group) matches")
+    rootCmd.Flags().Float64Var(&heatmapMin, "min", 0, "Heatmap value at the start of the gradient (default the smallest in the input)")
+    rootCmd.Flags().Float64Var(&heatmapMax, "max", 0, "Heatmap value at the end of the gradient (default the largest in the input)")
+    rootCmd.Flags().BoolVar(&logLevelMode, "loglevel-mode", false, "Color lines mentioning ERROR, WARN, INFO or DEBUG (and FATAL, CRITICAL, WARNING, TRACE) with the gradient of their severity")
+    for _, level := range []string{"error", "warn", "info", "debug"} {
+        rootCmd.Flags().StringSliceVar(levelStops[level], level+"-colors", defaultLevelStops[level], "Gradient stops for "+strings.ToUpper(level)+" lines with --loglevel-mode")
+    }
+    rootCmd.Flags().BoolVar(&cfg.StripANSI, "strip-ansi", false, "Remove escape sequences already present in the input before coloring, except OSC 8 hyperlinks")
+    rootCmd.Flags().Float64Var(&cfg.BlendExisting, "blend-existing", 0, "Mix colors already set in the input into the gradient in Lab space (0 ignores them, 1 keeps them)")
+    rootCmd.Flags().IntVar(&cfg.TabWidth, "tabs", 0, "Expand tabs to spaces with tab stops every N columns (0 leaves tabs as-is)")
+    rootCmd.Flags().StringVar(&scope, "scope", "all", "Gradient scope with several input files (all spans them, file restarts per file)")
+    rootCmd.Flags().BoolVar(&showHeaders, "headers", false, "Print a ==> name <== header before each input file")
+    rootCmd.Flags().BoolVar(&stream, "stream", false, "Color lines as they arrive instead of reading all input first (implies --per-line unless --period is set)")
+    rootCmd.Flags().BoolVar(&cfg.PerLine, "per-line", false, "Restart the horizontal gradient on every line")
+    rootCmd.Flags().Float64Var(&cfg.Period, "period", 0, "Repeat the gradient every N cells (or lines when vertical); 0 spans the whole input")
+    rootCmd.Flags().BoolVarP(&follow, "follow", "f", false, "Keep reading the input file as it grows, like tail -f, which takes a single file (implies --stream)")
+    rootCmd.Flags().BoolVarP(&animated, "animate", "a", false, "Redraw the text in place, shifting the gradient each frame")
+    rootCmd.Flags().IntVar(&fps, "fps", 30, "Frames per second when animating")
+    rootCmd.Flags().Float64Var(&speed, "speed", 0.5, "Gradient cycles per second when animating (negative reverses)")
+    rootCmd.Flags().DurationVar(&duration, "duration", 5*time.Second, "How long to animate (0 runs until interrupted)")
+    rootCmd.Flags().Float64VarP(&freq, "freq", "F", 0.1, "lolcat rainbow frequency; setting it or --spread selects lolcat-style cycling")
+    rootCmd.Flags().Float64VarP(&spread, "spread", "p", 3.0, "lolcat rainbow spread in characters")
+    rootCmd.Flags().Int64VarP(&seed, "seed", "S", 0, "Seed for randomized effects (0 picks one at random)")
+    rootCmd.Flags().Float64Var(&cfg.Offset, "offset", 0, "Shift the gradient by a fraction of a cycle")
+    rootCmd.Flags().StringVar(&randomKind, "random", "", "Blend between two random colors of a kind given after =, as in --random=warm (happy, warm); use --seed to repeat them")
+    rootCmd.Flags().Lookup("random").NoOptDefVal = "happy"
+    rootCmd.Flags().BoolVarP(&cfg.Rainbow, "rainbow", "r", false, "Sweep the full hue circle instead of blending --start-color to --end-color")
+    rootCmd.Flags().StringVar(&presetName, "preset", "", "Use a built-in multi-stop gradient or a preset saved with colorblend preset save (see --list-presets)")
+    rootCmd.Flags().BoolVar(&listPresetsFlag, "list-presets", false, "List the built-in gradient presets and exit")
+    rootCmd.Flags().StringVar(&listKind, "list", "", "List the available "+strings.Join(listKinds, ", ")+" and exit")
+    rootCmd.Flags().BoolVar(&listJSON, "json", false, "Print --list output as a JSON array")
+    rootCmd.Flags().StringVar(&paletteFile, "palette-file", "", "Use the colors of a GIMP .gpl, JSON hex array or Adobe .ase palette as gradient stops")
+    rootCmd.Flags().StringVar(&base16File, "base16", "", "Base16/Base24 YAML scheme whose base00..base17 names may be used as colors")
+    rootCmd.Flags().StringVar(&imageFile, "from-image", "", "Use the dominant colors of a PNG, JPEG or GIF image as gradient stops")
+    rootCmd.Flags().IntVar(&imageColors, "image-colors", 5, "Number of colors to extract with --from-image")
+    rootCmd.Flags().StringVar(&cfg.Colormap, "colormap", "", "Use a scientific colormap (viridis, magma, inferno, plasma, turbo) instead of a two-color blend")
+    rootCmd.Flags().StringVar(&cubehelixSpec, "cubehelix", "", "Use a cubehelix colormap with optional parameters after =, as in --cubehelix=rot=-1 (start, rot, hue, gamma)")
+    rootCmd.Flags().Lookup("cubehelix").NoOptDefVal = colorblend.DefaultCubehelix
+    rootCmd.Flags().IntVar(&jobs, "jobs", 0, "Worker goroutines for coloring large inputs (0 uses every CPU, 1 colors on one)")
+    rootCmd.Flags().BoolVar(&twoPass, "two-pass", false, "Measure input files in a first pass, then color them as they are read again, so huge files aren't held in memory")
+    rootCmd.Flags().IntVar(&totalUnits, "total-chars", 0, "Length of the input in cells (lines when vertical), letting the gradient span it while streaming")
+    rootCmd.Flags().IntVar(&benchRuns, "bench", 0, "Color the input N times, discard the output and report throughput and allocations")
+    rootCmd.Flags().StringVar(&configPath, "config", "", "Read default flag values from this TOML file (default ~/.config/colorblend/config.toml)")
+    rootCmd.PersistentFlags().StringVar(&errorFormat, "errors", "text", "How to report errors on stderr (text, or json for scripts)")
     rootCmd.Flags().BoolP("help", "h", false, "Show help message")
     rootCmd.Flags().BoolP("version", "v", false, "Show version information")
+    addAlias(rootCmd.Flags(), "gradient-direction", "direction", "d")
+    addAlias(rootCmd.Flags(), "steps", "num-steps", "n")
+
+    // bar's own range flags stand in for the heatmap ones
+    barCmd.Flags().Float64Var(&barValue, "value", 0, "How far the bar is filled, from 0 to --max")
+    barCmd.Flags().Float64Var(&barMax, "max", 100, "Value of a full bar")
+    barCmd.Flags().IntVar(&barWidth, "width", 0, "Width of the bar in cells (0 fills the terminal)")
+    barCmd.Flags().BoolVar(&barByValue, "by-value", false, "Color the whole bar by its value instead of along its length")
+
+    // Subcommands that color anything share the root's flags
+    for _, c := range []*cobra.Command{applyCmd, previewCmd, presetSaveCmd, demoCmd, bannerCmd, barCmd, ruleCmd, runCmd} {
+        c.Flags().AddFlagSet(rootCmd.Flags())
+    }
+
+    // watch's -n is its interval, as in watch(1), so it goes without the
+    // -n alias of --steps. Flags end at the command it runs.
+    watchCmd.Flags().Float64VarP(&watchInterval, "interval", "n", 2, "Seconds between runs of the command")
+    rootCmd.Flags().VisitAll(func(f *pflag.Flag) {
+        if f.Name != "num-steps" {
+            watchCmd.Flags().AddFlag(f)
+        }
+    })
+    watchCmd.Flags().SetInterspersed(false)
+    runCmd.Flags().SetInterspersed(false)
+    convertCmd.Flags().StringVar(&convertTo, "to", "hex", "Palette format to write ("+strings.Join(convertFormats, ", ")+")")
+    convertCmd.Flags().IntVar(&imageColors, "image-colors", 5, "Number of colors to extract from an image")
+    previewCmd.Flags().IntVar(&previewWidth, "width", 0, "Width of the bar in cells (0 fills the terminal)")
+    previewCmd.Flags().BoolVar(&previewLabels, "labels", false, "Print the hex color of each stop under the bar")
+    bannerCmd.Flags().StringVar(&bannerFont, "banner-font", "block", "Embedded font ("+strings.Join(figlet.Fonts(), ", ")+") or path of a FIGlet .flf file")
+    ruleCmd.Flags().StringVar(&ruleChar, "char", "─", "Character, or pattern of characters, the rule is drawn with")
+    ruleCmd.Flags().IntVar(&ruleWidth, "width", 0, "Width of the rule in cells (0 fills the terminal)")
+    presetCmd.AddCommand(presetSaveCmd, presetListCmd, presetDeleteCmd)
+    rootCmd.AddCommand(applyCmd, previewCmd, presetCmd, convertCmd, demoCmd, bannerCmd, barCmd, ruleCmd, watchCmd, runCmd)
 
     rootCmd.SetHelpFunc(func(cmd *cobra.Command, args []string) {
         cmd.SetOut(os.Stderr)
         cmd.Usage()
+        if cmd != rootCmd {
+            return
+        }
 
         fmt.Fprintln(os.Stderr, "\nExamples:")
         fmt.Fprintln(os.Stderr, "  echo \"Hello, World!\" | colorblend")
         fmt.Fprintln(os.Stderr, "  echo \"Colorful!\" | colorblend --start-color #FF0000 --end-color #00FF00")
-        fmt.Fprintln(os.Stderr, "  cat my_file.txt | colorblend --start-color #FFFF00 --end-color #0000FF")
+        fmt.Fprintln(os.Stderr, "  colorblend --start-color #FFFF00 --end-color #0000FF my_file.txt")
+        fmt.Fprintln(os.Stderr, "  date | colorblend header.txt - footer.txt")
+        fmt.Fprintln(os.Stderr, "  colorblend --scope file --headers *.go")
+        fmt.Fprintln(os.Stderr, "  ping localhost | colorblend --stream")
+        fmt.Fprintln(os.Stderr, "  dmesg | colorblend --period 40")
+        fmt.Fprintln(os.Stderr, "  colorblend -f --period 80 /var/log/app.log")
+        fmt.Fprintln(os.Stderr, "  figlet Hello | colorblend --animate --duration 3s")
+        fmt.Fprintln(os.Stderr, "  fortune | colorblend --freq 0.3 --spread 2 --seed 42")
+        fmt.Fprintln(os.Stderr, "  echo \"Sparkle\" | colorblend --jitter 0.08 --seed 7")
+        fmt.Fprintln(os.Stderr, "  fortune | colorblend --random")
+        fmt.Fprintln(os.Stderr, "  echo \"For the blog\" | colorblend --format html > title.html")
+        fmt.Fprintln(os.Stderr, "  figlet Hello | colorblend --format html-page --theme light > hello.html")
+        fmt.Fprintln(os.Stderr, "  figlet Banner | colorblend --format svg --background '#000000' > banner.svg")
+        fmt.Fprintln(os.Stderr, "  figlet Banner | colorblend --preset vaporwave --format png -o banner.png")
+        fmt.Fprintln(os.Stderr, "  echo \"Hello channel\" | colorblend --format irc --irc-colors 16")
+        fmt.Fprintln(os.Stderr, "  echo \"Forum title\" | colorblend --format bbcode --steps 8")
+        fmt.Fprintln(os.Stderr, "  figlet Memo | colorblend --format rtf -o memo.rtf")
+        fmt.Fprintln(os.Stderr, "  colorblend --format latex --latex-preamble -o listing.tex < main.go")
+        fmt.Fprintln(os.Stderr, "  date +%H:%M | colorblend --format pango")
+        fmt.Fprintln(os.Stderr, "  set -g status-left \"#(hostname | colorblend --format tmux)\"")
+        fmt.Fprintln(os.Stderr, "  PROMPT=\"$(hostname | colorblend --format zsh) %# \"")
+        fmt.Fprintln(os.Stderr, "  PS1=\"$(hostname | colorblend --format bash-prompt) \\\\$ \"")
+        fmt.Fprintln(os.Stderr, "  function fish_prompt; prompt_pwd | colorblend --format fish | source; printf ' > '; end")
+        fmt.Fprintln(os.Stderr, "  echo \"Same every time\" | colorblend --random=warm --seed 1234")
+        fmt.Fprintln(os.Stderr, "  echo \"Over the rainbow\" | colorblend --rainbow --steps 7")
+        fmt.Fprintln(os.Stderr, "  echo \"Good evening\" | colorblend --preset sunset")
+        fmt.Fprintln(os.Stderr, "  colorblend --palette-file brand.gpl < banner.txt")
+        fmt.Fprintln(os.Stderr, "  colorblend --base16 ocean.yaml --start-color base08 --end-color base0D < banner.txt")
+        fmt.Fprintln(os.Stderr, "  colorblend --from-image wallpaper.png --image-colors 4 < motd.txt")
+        fmt.Fprintln(os.Stderr, "  seq 20 | colorblend --colormap viridis -g vertical")
+        fmt.Fprintln(os.Stderr, "  seq 20 | colorblend --cubehelix=start=0.5,rot=-1.5 -g vertical")
         fmt.Fprintln(os.Stderr, "  echo \"Stepped!\" | colorblend --steps 5 --start-color #FF0000 --end-color #0000FF")
         fmt.Fprintln(os.Stderr, "  echo \"Vertical!\" | colorblend --gradient-direction vertical --start-color #FF0000 --end-color #0000FF")
         fmt.Fprintln(os.Stderr, "  echo \"Inverted!\" | colorblend --invert")
-        fmt.Fprintln(os.Stderr, "  echo \"Color Direction\" | colorblend --color-direction # (default is ccw)")
+        fmt.Fprintln(os.Stderr, "  seq 12 | colorblend -s #FF0000 -e #0000FF -d v -n 4 -i")
+        fmt.Fprintln(os.Stderr, "  echo \"Color Direction\" | colorblend --hue-direction longest")
+        fmt.Fprintln(os.Stderr, "  echo \"Vivid!\" | colorblend --colorspace hsv --start-color #FF0000 --end-color #0000FF")
+        fmt.Fprintln(os.Stderr, "  echo \"Like CSS\" | colorblend --colorspace hsl --color-direction cw")
+        fmt.Fprintln(os.Stderr, "  echo \"Perceptual\" | colorblend --colorspace oklch")
+        fmt.Fprintln(os.Stderr, "  echo \"Even saturation\" | colorblend --colorspace hsluv")
+        fmt.Fprintln(os.Stderr, "  echo \"No dark midpoint\" | colorblend --colorspace linear-rgb --gamma 2.2")
+        fmt.Fprintln(os.Stderr, "  echo \"Smooth ramp\" | colorblend --colors '#000000,#FF0000,#FFFF00,#FFFFFF' --interpolation bezier --colorspace lab")
+        fmt.Fprintln(os.Stderr, "  echo \"Brand colors\" | colorblend --colors '#0B3D91,#FC3D21,#FFFFFF' --interpolation spline")
+        fmt.Fprintln(os.Stderr, "  echo \"Late bloomer\" | colorblend --easing expo")
+        fmt.Fprintln(os.Stderr, "  echo \"Web curve\" | colorblend --easing 'cubic-bezier(0.25,0.1,0.25,1)'")
+        fmt.Fprintln(os.Stderr, "  colorblend --depth 256 --dither < long_banner.txt")
+        fmt.Fprintln(os.Stderr, "  colorblend --depth 16 --dither=ordered < long_banner.txt")
+        fmt.Fprintln(os.Stderr, "  echo \"Light terminal\" | colorblend --preset sunset --brighten -0.2 --saturate 1.3")
+        fmt.Fprintln(os.Stderr, "  ls | colorblend --color always | less -R")
+        fmt.Fprintln(os.Stderr, "  ls --color=always | colorblend --strip-ansi")
+        fmt.Fprintln(os.Stderr, "  bat --color=always main.go | colorblend --blend-existing 0.5")
+        fmt.Fprintln(os.Stderr, "  colorblend --tabs 4 < Makefile")
+        fmt.Fprintln(os.Stderr, "  colorblend --errors json --start-color nope < motd.txt")
+        fmt.Fprintln(os.Stderr, "  colorblend apply --preset sunset notes.txt")
+        fmt.Fprintln(os.Stderr, "  colorblend convert wallpaper.png --image-colors 4 --to gpl > wallpaper.gpl")
+        fmt.Fprintln(os.Stderr, "  colorblend demo --colorspace oklch")
+        fmt.Fprintln(os.Stderr, "  colorblend --list formats --json")
+        fmt.Fprintln(os.Stderr, "  colorblend preview --colors '#0B3D91,#FC3D21,#FFFFFF' --labels")
+        fmt.Fprintln(os.Stderr, "  colorblend --config brand.toml < banner.txt")
+        fmt.Fprintln(os.Stderr, "  colorblend preset save brand --colors '#0B3D91,#FC3D21' --colorspace oklch && colorblend --preset brand < banner.txt")
+        fmt.Fprintln(os.Stderr, "  colorblend --two-pass huge.log > colored.log")
+        fmt.Fprintln(os.Stderr, "  seq 1000000 | colorblend -g vertical --total-chars 1000000 > numbers.txt")
+        fmt.Fprintln(os.Stderr, "  colorblend --bench 10 --depth 256 < big.log")
+        fmt.Fprintln(os.Stderr, "  colorblend --jobs 4 --color always < huge.log > colored.log")
+        fmt.Fprintln(os.Stderr, "  colorblend --final-reset line < motd.txt | grep -i welcome")
+        fmt.Fprintln(os.Stderr, "  colorblend --color always < README.txt > README.ans  # CRLF endings are kept")
+        fmt.Fprintln(os.Stderr, "  colorblend --skip-whitespace=progress < table.txt")
+        fmt.Fprintln(os.Stderr, "  colorblend --per-line --ignore-indent < main.go")
+        fmt.Fprintln(os.Stderr, "  tail -f app.log | colorblend --stream --match '[0-9a-f]{8}-[0-9a-f-]{27}'")
+        fmt.Fprintln(os.Stderr, "  colorblend --exclude '`[^`]*`' --match-progress global < NOTES.md")
+        fmt.Fprintln(os.Stderr, "  journalctl -f | colorblend --stream --loglevel-mode --error-colors '#FF0000,#FF00AA'")
+        fmt.Fprintln(os.Stderr, "  kubectl logs -l app=web --prefix | colorblend --rainbow --hash-by '^\\[pod/([^/ ]+)'")
+        fmt.Fprintln(os.Stderr, "  colorblend --progress-by-time --colormap turbo < server.log")
+        fmt.Fprintln(os.Stderr, "  colorblend --progress-by-time --time-regex '^\\[([0-9.]+)\\]' --time-format unix < dmesg.log")
+        fmt.Fprintln(os.Stderr, "  du -sh * | colorblend --heatmap-field 1 --colormap inferno")
+        fmt.Fprintln(os.Stderr, "  ps aux | colorblend --heatmap-field 3 --min 0 --max 100 --colors '#00FF00,#FFFF00,#FF0000'")
+        fmt.Fprintln(os.Stderr, "  colorblend --csv --rainbow < report.csv")
+        fmt.Fprintln(os.Stderr, "  colorblend --tsv --field-progress field < table.tsv")
+        fmt.Fprintln(os.Stderr, "  curl -s https://api.github.com/repos/ocodo/colorblend | colorblend --json-pretty")
+        fmt.Fprintln(os.Stderr, "  colorblend --markdown --colors '#F5C2E7,#89B4FA' README.md | less -R")
+        fmt.Fprintln(os.Stderr, "  git diff | colorblend --diff-mode --color always | less -R")
+        fmt.Fprintln(os.Stderr, "  colorblend banner \"Hello\" --banner-font small --preset sunset")
+        fmt.Fprintln(os.Stderr, "  echo \"Welcome to $(hostname)\" | colorblend --box=double --box-padding 2 --box-padding-y 1")
+        fmt.Fprintln(os.Stderr, "  colorblend bar --value 42 --max 100 --width 40 --colors '#FF0000,#FFFF00,#00FF00'")
+        fmt.Fprintln(os.Stderr, "  colorblend rule --char '═' --preset ocean")
+        fmt.Fprintln(os.Stderr, "  colorblend preview --colors '#FF0000,#00FF00' --simulate deuteranopia")
+        fmt.Fprintln(os.Stderr, "  colorblend --background '#1E1E2E' --min-contrast 4.5 --colors '#313244,#F5C2E7' notes.txt")
+        fmt.Fprintln(os.Stderr, "  colorblend --colormap magma --min-luminance 35 README.md")
+        fmt.Fprintln(os.Stderr, "  colorblend --preset ocean --detect-background motd.txt")
+        fmt.Fprintln(os.Stderr, "  echo \"CSS colors\" | colorblend --colors 'rgb(255,0,255),hsl(180,100%,50%),#FF0'")
+        fmt.Fprintln(os.Stderr, "  df -h | colorblend --colors '#00FF00@0,#FFFF00@0.8,#FF0000@1'")
+        fmt.Fprintln(os.Stderr, "  echo \"Release notes\" | colorblend --bold --underline --preset fire")
+        fmt.Fprintln(os.Stderr, "  echo \"~/src/colorblend on main\" | colorblend --fade out --background '#1E1E2E'")
+        fmt.Fprintln(os.Stderr, "  echo \"Spelling mistaek\" | colorblend --target underline --preset fire")
+        fmt.Fprintln(os.Stderr, "  ls --hyperlink=always | colorblend --format html > files.html")
+        fmt.Fprintln(os.Stderr, "  colorblend watch -n 5 --preset ocean -- df -h")
+        fmt.Fprintln(os.Stderr, "  colorblend run --preset fire -- make test")
+
+        fmt.Fprintln(os.Stderr, "\nExit codes:")
+        fmt.Fprintln(os.Stderr, "  0    success")
+        fmt.Fprintln(os.Stderr, "  1    other failures")
+        fmt.Fprintln(os.Stderr, "  2    invalid flags or arguments")
+        fmt.Fprintln(os.Stderr, "  3    invalid colors, palettes or images")
+        fmt.Fprintln(os.Stderr, "  4    reading or writing files failed")
+        fmt.Fprintln(os.Stderr, "  141  output pipe closed")
     })
 
     rootCmd.SetVersionTemplate("colorblend v1.0.0\n")
 
     rootCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+        syncAliases(cmd.Flags())
         if v, _ := cmd.Flags().GetBool("version"); v {
             fmt.Println("colorblend v1.0.0")
             os.Exit(0)
@@ -245,8 +1010,13 @@ func init() {
 }
 
 func main() {
-    if err := rootCmd.Execute(); err != nil {
-        fmt.Fprintf(os.Stderr, "%v\n", err)
-        os.Exit(1)
+    // Writing to a closed pipe then fails with EPIPE, which is reported
+    // with its own exit code, instead of killing the process
+    signal.Notify(make(chan os.Signal, 1), syscall.SIGPIPE)
+
+    rootCmd.SilenceErrors = true
+    rootCmd.SilenceUsage = true
+    if cmd, err := rootCmd.ExecuteC(); err != nil {
+        usageError(cmd, "%v", err)
     }
 }
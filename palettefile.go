@@ -0,0 +1,180 @@
+package main
+
+import (
+    "bufio"
+    "bytes"
+    "encoding/binary"
+    "encoding/json"
+    "fmt"
+    "io"
+    "os"
+    "path/filepath"
+    "strconv"
+    "strings"
+    "unicode/utf16"
+
+    "github.com/lucasb-eyer/go-colorful"
+)
+
+// loadPaletteFile reads gradient stops from a GIMP .gpl palette, a JSON
+// array of hex strings or an Adobe .ase swatch file. The format is picked
+// from the extension, falling back to sniffing the content.
+func loadPaletteFile(path string) ([]string, error) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, err
+    }
+
+    var stops []string
+    switch ext := strings.ToLower(filepath.Ext(path)); {
+    case ext == ".gpl" || bytes.HasPrefix(data, []byte("GIMP Palette")):
+        stops, err = parseGPL(data)
+    case ext == ".ase" || bytes.HasPrefix(data, []byte("ASEF")):
+        stops, err = parseASE(data)
+    case ext == ".json" || bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")):
+        stops, err = parseJSONPalette(data)
+    default:
+        return nil, fmt.Errorf("%s: unrecognized palette format (expected .gpl, .json or .ase)", path)
+    }
+    if err != nil {
+        return nil, fmt.Errorf("%s: %w", path, err)
+    }
+    if len(stops) == 0 {
+        return nil, fmt.Errorf("%s: palette has no colors", path)
+    }
+    return stops, nil
+}
+
+// parseGPL reads the "R G B name" rows of a GIMP palette.
+func parseGPL(data []byte) ([]string, error) {
+    var stops []string
+    scanner := bufio.NewScanner(bytes.NewReader(data))
+    for lineNo := 1; scanner.Scan(); lineNo++ {
+        line := strings.TrimSpace(scanner.Text())
+        if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "GIMP Palette") ||
+            strings.HasPrefix(line, "Name:") || strings.HasPrefix(line, "Columns:") {
+            continue
+        }
+        fields := strings.Fields(line)
+        if len(fields) < 3 {
+            return nil, fmt.Errorf("line %d: expected R G B values", lineNo)
+        }
+        var rgb [3]uint8
+        for i := range rgb {
+            v, err := strconv.ParseUint(fields[i], 10, 8)
+            if err != nil {
+                return nil, fmt.Errorf("line %d: invalid channel value %q", lineNo, fields[i])
+            }
+            rgb[i] = uint8(v)
+        }
+        stops = append(stops, fmt.Sprintf("#%02X%02X%02X", rgb[0], rgb[1], rgb[2]))
+    }
+    return stops, scanner.Err()
+}
+
+// parseJSONPalette reads a JSON array of hex color strings.
+func parseJSONPalette(data []byte) ([]string, error) {
+    var stops []string
+    if err := json.Unmarshal(data, &stops); err != nil {
+        return nil, err
+    }
+    for _, stop := range stops {
+        if _, err := colorful.Hex(stop); err != nil {
+            return nil, fmt.Errorf("invalid hex color: %s", stop)
+        }
+    }
+    return stops, nil
+}
+
+// aseColorEntry is the ASE block type of a swatch; group start and end
+// blocks carry no colors and are skipped.
+const aseColorEntry = 0x0001
+
+// parseASE reads the color entries of an Adobe Swatch Exchange file,
+// flattening any groups.
+func parseASE(data []byte) ([]string, error) {
+    r := bytes.NewReader(data)
+    var header struct {
+        Magic  [4]byte
+        Major  uint16
+        Minor  uint16
+        Blocks uint32
+    }
+    if err := binary.Read(r, binary.BigEndian, &header); err != nil || string(header.Magic[:]) != "ASEF" {
+        return nil, fmt.Errorf("not an ASE file")
+    }
+
+    var stops []string
+    for i := uint32(0); i < header.Blocks; i++ {
+        var blockType uint16
+        var length uint32
+        if err := binary.Read(r, binary.BigEndian, &blockType); err != nil {
+            return nil, fmt.Errorf("truncated block header")
+        }
+        if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+            return nil, fmt.Errorf("truncated block header")
+        }
+        block := make([]byte, length)
+        if _, err := io.ReadFull(r, block); err != nil {
+            return nil, fmt.Errorf("truncated block")
+        }
+        if blockType != aseColorEntry {
+            continue
+        }
+        stop, err := parseASEColor(block)
+        if err != nil {
+            return nil, err
+        }
+        stops = append(stops, stop)
+    }
+    return stops, nil
+}
+
+// parseASEColor decodes one color entry block: a UTF-16 name, a four
+// character color model and its float32 components.
+func parseASEColor(block []byte) (string, error) {
+    r := bytes.NewReader(block)
+    var nameLen uint16
+    if err := binary.Read(r, binary.BigEndian, &nameLen); err != nil {
+        return "", fmt.Errorf("truncated color entry")
+    }
+    name := make([]uint16, nameLen)
+    if err := binary.Read(r, binary.BigEndian, name); err != nil {
+        return "", fmt.Errorf("truncated color name")
+    }
+    var model [4]byte
+    if err := binary.Read(r, binary.BigEndian, &model); err != nil {
+        return "", fmt.Errorf("truncated color model")
+    }
+
+    components := map[string]int{"RGB ": 3, "LAB ": 3, "CMYK": 4, "Gray": 1}
+    n, ok := components[string(model[:])]
+    if !ok {
+        return "", fmt.Errorf("color %q: unsupported color model %q", string(utf16.Decode(trimNul(name))), string(model[:]))
+    }
+    v := make([]float32, n)
+    if err := binary.Read(r, binary.BigEndian, v); err != nil {
+        return "", fmt.Errorf("truncated color values")
+    }
+
+    var c colorful.Color
+    switch string(model[:]) {
+    case "RGB ":
+        c = colorful.Color{R: float64(v[0]), G: float64(v[1]), B: float64(v[2])}
+    case "LAB ":
+        c = colorful.Lab(float64(v[0]), float64(v[1])/100, float64(v[2])/100)
+    case "CMYK":
+        k := 1 - float64(v[3])
+        c = colorful.Color{R: (1 - float64(v[0])) * k, G: (1 - float64(v[1])) * k, B: (1 - float64(v[2])) * k}
+    case "Gray":
+        c = colorful.Color{R: float64(v[0]), G: float64(v[0]), B: float64(v[0])}
+    }
+    return strings.ToUpper(c.Clamped().Hex()), nil
+}
+
+func trimNul(s []uint16) []uint16 {
+    if i := len(s) - 1; i >= 0 && s[i] == 0 {
+        return s[:i]
+    }
+    return s
+}
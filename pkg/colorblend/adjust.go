@@ -0,0 +1,106 @@
+package colorblend
+
+import (
+    "math"
+
+    "github.com/lucasb-eyer/go-colorful"
+)
+
+// adjust applies Saturate, Brighten and Contrast to a gradient color,
+// then the luminance band. Saturation scales OKLCH chroma and brightness
+// shifts OKLab lightness, so both keep the hue; contrast stretches RGB
+// around mid gray.
+func (cfg *Config) adjust(c colorful.Color) colorful.Color {
+    if cfg.Saturate != 1 || cfg.Brighten != 0 || cfg.Contrast != 1 {
+        l, chroma, h := toOklch(c)
+        c = fromOklch(l+cfg.Brighten, chroma*cfg.Saturate, h)
+        if cfg.Contrast != 1 {
+            c = colorful.Color{
+                R: (c.R-0.5)*cfg.Contrast + 0.5,
+                G: (c.G-0.5)*cfg.Contrast + 0.5,
+                B: (c.B-0.5)*cfg.Contrast + 0.5,
+            }
+        }
+        c = c.Clamped()
+    }
+    return cfg.clampLuminance(c)
+}
+
+// finalColor applies MinContrast and Simulate to a color about to be written.
+// They come last, after jitter, blending and fades, as they are about
+// how the written color reads.
+func (cfg *Config) finalColor(c colorful.Color) colorful.Color {
+    return cfg.simulate(cfg.ensureContrast(c))
+}
+
+// clampLuminance brings the CIE L* of c into MinLuminance..MaxLuminance,
+// keeping its Lab hue and chroma.
+func (cfg *Config) clampLuminance(c colorful.Color) colorful.Color {
+    if cfg.MinLuminance <= 0 && cfg.MaxLuminance >= 100 {
+        return c
+    }
+    h, chroma, l := c.Hcl()
+    clamped := math.Max(cfg.MinLuminance/100, math.Min(l, cfg.MaxLuminance/100))
+    if clamped == l {
+        return c
+    }
+    return colorful.Hcl(h, chroma, clamped).Clamped()
+}
+
+// ensureContrast lightens or darkens c, keeping its OKLCH hue and chroma,
+// until its WCAG contrast ratio with the background reaches MinContrast.
+// It moves toward white on dark backgrounds and black on light ones, and
+// stops there when the ratio can't be reached.
+func (cfg *Config) ensureContrast(c colorful.Color) colorful.Color {
+    if cfg.MinContrast <= 0 {
+        return c
+    }
+    bg, err := colorful.Hex(cfg.pageBackground())
+    if err != nil || contrastRatio(c, bg) >= cfg.MinContrast {
+        return c
+    }
+    l, chroma, h := toOklch(c)
+    target := 0.0
+    if contrastRatio(colorful.Color{R: 1, G: 1, B: 1}, bg) > contrastRatio(colorful.Color{}, bg) {
+        target = 1
+    }
+    // Contrast grows steadily on the way to the target, so bisect for
+    // the lightness closest to c that is enough
+    near, far := l, target
+    for i := 0; i < 24; i++ {
+        mid := (near + far) / 2
+        if contrastRatio(fromOklch(mid, chroma, h).Clamped(), bg) >= cfg.MinContrast {
+            far = mid
+        } else {
+            near = mid
+        }
+    }
+    return fromOklch(far, chroma, h).Clamped()
+}
+
+// contrastRatio is the WCAG 2 contrast ratio of two colors, from 1 to 21.
+func contrastRatio(a, b colorful.Color) float64 {
+    la, lb := relativeLuminance(a), relativeLuminance(b)
+    if la < lb {
+        la, lb = lb, la
+    }
+    return (la + 0.05) / (lb + 0.05)
+}
+
+// relativeLuminance is the WCAG relative luminance of c.
+func relativeLuminance(c colorful.Color) float64 {
+    r, g, b := c.LinearRgb()
+    return 0.2126*r + 0.7152*g + 0.0722*b
+}
+
+// jitter nudges c by up to Jitter in each Lab component.
+func (cfg *Config) jitter(c colorful.Color) colorful.Color {
+    if cfg.Jitter == 0 || cfg.Rand == nil {
+        return c
+    }
+    l, a, b := c.Lab()
+    l += (cfg.Rand.Float64()*2 - 1) * cfg.Jitter
+    a += (cfg.Rand.Float64()*2 - 1) * cfg.Jitter
+    b += (cfg.Rand.Float64()*2 - 1) * cfg.Jitter
+    return colorful.Lab(l, a, b).Clamped()
+}
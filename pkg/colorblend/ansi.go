@@ -0,0 +1,276 @@
+package colorblend
+
+import (
+    "strconv"
+    "strings"
+
+    "github.com/lucasb-eyer/go-colorful"
+    "github.com/rivo/uniseg"
+)
+
+const esc = '\x1b'
+
+// segment is one unit of an input line: either a printable grapheme
+// cluster or an escape sequence that was already present in the input and
+// is copied through verbatim without consuming gradient progress.
+type segment struct {
+    text   string
+    width  int
+    escape string
+    // eol marks the segment holding the ending the line was read with,
+    // which is always last. Its text is empty when the input ended
+    // without one.
+    eol bool
+}
+
+func (s segment) isEscape() bool {
+    return s.escape != ""
+}
+
+// Line is one parsed line of input, ready to be painted.
+type Line []segment
+
+// String returns the line as it was read, escapes included.
+func (l Line) String() string {
+    return segmentsString(l)
+}
+
+// Text returns the line's text without its escapes.
+func (l Line) Text() string {
+    return plainText(l)
+}
+
+// Width returns the number of terminal cells the line takes up.
+func (l Line) Width() int {
+    return visibleWidth(l)
+}
+
+// ParseLine splits a raw line of input into grapheme clusters and escapes,
+// then applies StripANSI and TabWidth. Escapes never take up gradient
+// progress.
+func (cfg *Config) ParseLine(raw string) Line {
+    line := parseSegments(raw)
+    if cfg.StripANSI {
+        line = stripEscapes(line)
+    }
+    if cfg.TabWidth > 0 {
+        line = expandTabs(line, cfg.TabWidth)
+    }
+    return line
+}
+
+// ParseLineEnding is ParseLine for a line read together with its line
+// ending, which is written back out in place of the usual line break.
+// ending is empty for a last line that had none.
+func (cfg *Config) ParseLineEnding(raw, ending string) Line {
+    return append(cfg.ParseLine(raw), segment{text: ending, eol: true})
+}
+
+// splitEnding separates a line from the ending it was read with, if any.
+func splitEnding(line Line) (Line, string, bool) {
+    if n := len(line); n > 0 && line[n-1].eol {
+        return line[:n-1], line[n-1].text, true
+    }
+    return line, "", false
+}
+
+// parseSegments splits a line into grapheme clusters and the CSI, OSC and
+// two-byte escape sequences embedded in it. Clusters such as ZWJ emoji,
+// flags and base characters with combining marks stay in one segment so
+// they are never split across color changes.
+func parseSegments(line string) []segment {
+    segments := make([]segment, 0, len(line))
+    for len(line) > 0 {
+        i := strings.IndexByte(line, esc)
+        if i != 0 {
+            text := line
+            if i > 0 {
+                text = line[:i]
+            }
+            graphemes := uniseg.NewGraphemes(text)
+            for graphemes.Next() {
+                segments = append(segments, segment{text: graphemes.Str(), width: graphemes.Width()})
+            }
+            line = line[len(text):]
+            continue
+        }
+        end := escapeEnd(line)
+        segments = append(segments, segment{escape: line[:end]})
+        line = line[end:]
+    }
+    return segments
+}
+
+// escapeEnd returns the index just past the escape sequence at the start
+// of s. Unterminated sequences run to the end of the line.
+func escapeEnd(s string) int {
+    i := 1
+    if i >= len(s) {
+        return i
+    }
+    switch s[i] {
+    case '[':
+        // CSI: parameter and intermediate bytes, then a final byte in @..~
+        for i++; i < len(s); i++ {
+            if s[i] >= 0x40 && s[i] <= 0x7e {
+                return i + 1
+            }
+        }
+        return i
+    case ']', 'P', '_', '^', 'X':
+        // OSC and other string sequences end with BEL or ST (ESC \)
+        for i++; i < len(s); i++ {
+            if s[i] == '\a' {
+                return i + 1
+            }
+            if s[i] == esc && i+1 < len(s) && s[i+1] == '\\' {
+                return i + 2
+            }
+        }
+        return i
+    default:
+        // nF sequences like ESC ( B carry intermediate bytes before the final
+        for i < len(s) && s[i] >= 0x20 && s[i] <= 0x2f {
+            i++
+        }
+        if i < len(s) {
+            i++
+        }
+        return i
+    }
+}
+
+// stripEscapes drops every escape sequence from a line, leaving only
+// printable clusters and OSC 8 hyperlinks, which aren't styling.
+func stripEscapes(line []segment) []segment {
+    stripped := line[:0]
+    for _, s := range line {
+        if _, link := hyperlink(s.escape); link || !s.isEscape() {
+            stripped = append(stripped, s)
+        }
+    }
+    return stripped
+}
+
+// hyperlink returns the URI an OSC 8 escape links the text after it to,
+// empty for the escape ending a link, and whether escape is one.
+func hyperlink(escape string) (string, bool) {
+    rest, ok := strings.CutPrefix(escape, "\x1b]8;")
+    if !ok {
+        return "", false
+    }
+    // Parameters such as id=... come before the URI
+    _, uri, ok := strings.Cut(rest, ";")
+    if !ok {
+        return "", false
+    }
+    uri = strings.TrimSuffix(strings.TrimSuffix(uri, "\a"), "\x1b\\")
+    return uri, true
+}
+
+// expandTabs replaces tab characters with spaces up to the next multiple
+// of tabWidth display columns.
+func expandTabs(line []segment, tabWidth int) []segment {
+    expanded := make([]segment, 0, len(line))
+    column := 0
+    for _, s := range line {
+        if s.text != "\t" {
+            expanded = append(expanded, s)
+            column += s.width
+            continue
+        }
+        for n := tabWidth - column%tabWidth; n > 0; n-- {
+            expanded = append(expanded, segment{text: " ", width: 1})
+            column++
+        }
+    }
+    return expanded
+}
+
+// visibleLen counts the grapheme clusters in a line, ignoring escapes.
+func visibleLen(line []segment) int {
+    n := 0
+    for _, s := range line {
+        if !s.isEscape() && !s.eol {
+            n++
+        }
+    }
+    return n
+}
+
+// visibleWidth sums the terminal cell widths of a line, so wide East Asian
+// characters count twice and zero-width clusters not at all.
+func visibleWidth(line []segment) int {
+    n := 0
+    for _, s := range line {
+        n += s.width
+    }
+    return n
+}
+
+// segmentsString reassembles a line exactly as it was read.
+func segmentsString(line []segment) string {
+    var b strings.Builder
+    for _, s := range line {
+        if s.isEscape() {
+            b.WriteString(s.escape)
+        } else {
+            b.WriteString(s.text)
+        }
+    }
+    return b.String()
+}
+
+// inputColor tracks the foreground color selected by SGR sequences in the
+// input, so it can be mixed with the gradient.
+type inputColor struct {
+    set   bool
+    color colorful.Color
+}
+
+// apply updates the tracked foreground color from an SGR escape. Other
+// escapes and attributes are ignored.
+func (c *inputColor) apply(escape string) {
+    if !strings.HasPrefix(escape, "\x1b[") || !strings.HasSuffix(escape, "m") {
+        return
+    }
+    params := strings.FieldsFunc(escape[2:len(escape)-1], func(r rune) bool {
+        return r == ';' || r == ':'
+    })
+    if len(params) == 0 {
+        c.set = false
+        return
+    }
+    for i := 0; i < len(params); i++ {
+        n, err := strconv.Atoi(params[i])
+        if err != nil {
+            continue
+        }
+        switch {
+        case n == 0 || n == 39:
+            c.set = false
+        case n >= 30 && n <= 37:
+            c.set, c.color = true, xterm256(n-30)
+        case n >= 90 && n <= 97:
+            c.set, c.color = true, xterm256(n-90+8)
+        case n == 38 && i+2 < len(params) && params[i+1] == "5":
+            if idx, err := strconv.Atoi(params[i+2]); err == nil && idx >= 0 && idx < 256 {
+                c.set, c.color = true, xterm256(idx)
+            }
+            i += 2
+        case n == 38 && i+4 < len(params) && params[i+1] == "2":
+            r, _ := strconv.Atoi(params[i+2])
+            g, _ := strconv.Atoi(params[i+3])
+            b, _ := strconv.Atoi(params[i+4])
+            c.set, c.color = true, colorful.Color{R: float64(r) / 255, G: float64(g) / 255, B: float64(b) / 255}.Clamped()
+            i += 4
+        case n == 48 || n == 58:
+            // Skip the operands of background and underline colors
+            if i+1 < len(params) && params[i+1] == "5" {
+                i += 2
+            } else if i+1 < len(params) && params[i+1] == "2" {
+                i += 4
+            }
+        }
+    }
+}
@@ -0,0 +1,116 @@
+package colorblend
+
+import (
+    "fmt"
+    "math"
+    "strconv"
+    "strings"
+
+    "github.com/lucasb-eyer/go-colorful"
+)
+
+// ParseColor parses a color written in hex, as #RGB or #RRGGBB, or in the
+// CSS style rgb(), hsl() and hsv() forms, such as rgb(255,0,255),
+// rgb(100% 0% 100%), hsl(300,100%,50%) and hsv(300deg 100% 100%). An
+// alpha argument is accepted and ignored.
+func ParseColor(s string) (colorful.Color, error) {
+    s = strings.TrimSpace(s)
+    if strings.HasPrefix(s, "#") {
+        if len(s) != 4 && len(s) != 7 {
+            return colorful.Color{}, fmt.Errorf("invalid hex color: %s", s)
+        }
+        return colorful.Hex(s)
+    }
+
+    open := strings.IndexByte(s, '(')
+    if open < 0 || !strings.HasSuffix(s, ")") {
+        return colorful.Color{}, fmt.Errorf("invalid color: %s", s)
+    }
+    fn := strings.TrimSuffix(strings.ToLower(s[:open]), "a")
+    args := strings.FieldsFunc(s[open+1:len(s)-1], func(r rune) bool {
+        return r == ',' || r == '/' || r == ' ' || r == '\t'
+    })
+    if len(args) != 3 && len(args) != 4 {
+        return colorful.Color{}, fmt.Errorf("invalid color: %s needs 3 arguments", s)
+    }
+
+    switch fn {
+    case "rgb":
+        var rgb [3]float64
+        for i := range rgb {
+            v, err := colorArg(args[i], 255)
+            if err != nil {
+                return colorful.Color{}, fmt.Errorf("invalid color: %s (%w)", s, err)
+            }
+            rgb[i] = v
+        }
+        return colorful.Color{R: rgb[0], G: rgb[1], B: rgb[2]}, nil
+    case "hsl", "hsv":
+        h, err := strconv.ParseFloat(strings.TrimSuffix(strings.ToLower(args[0]), "deg"), 64)
+        if err != nil {
+            return colorful.Color{}, fmt.Errorf("invalid color: %s (bad hue %s)", s, args[0])
+        }
+        // Saturation and lightness (or value) are percentages, with or
+        // without the % sign
+        sat, err := colorArg(strings.TrimSuffix(args[1], "%")+"%", 0)
+        if err != nil {
+            return colorful.Color{}, fmt.Errorf("invalid color: %s (%w)", s, err)
+        }
+        light, err := colorArg(strings.TrimSuffix(args[2], "%")+"%", 0)
+        if err != nil {
+            return colorful.Color{}, fmt.Errorf("invalid color: %s (%w)", s, err)
+        }
+        h = math.Mod(h, 360)
+        if h < 0 {
+            h += 360
+        }
+        if fn == "hsl" {
+            return colorful.Hsl(h, sat, light).Clamped(), nil
+        }
+        return colorful.Hsv(h, sat, light).Clamped(), nil
+    }
+    return colorful.Color{}, fmt.Errorf("invalid color: %s (unknown function %s)", s, s[:open])
+}
+
+// colorArg parses one argument of a color function to 0..1: a percentage,
+// or a number out of scale.
+func colorArg(arg string, scale float64) (float64, error) {
+    percent := strings.HasSuffix(arg, "%")
+    v, err := strconv.ParseFloat(strings.TrimSuffix(arg, "%"), 64)
+    if err != nil {
+        return 0, fmt.Errorf("bad argument %s", arg)
+    }
+    if percent {
+        scale = 100
+    }
+    if v < 0 || v > scale {
+        return 0, fmt.Errorf("argument %s out of range", arg)
+    }
+    return v / scale, nil
+}
+
+// NormalizeColor parses a color as ParseColor does and writes it as
+// #RRGGBB hex, the form colors are kept in.
+func NormalizeColor(s string) (string, error) {
+    c, err := ParseColor(s)
+    if err != nil {
+        return "", err
+    }
+    return strings.ToUpper(c.Hex()), nil
+}
+
+// JoinColorArgs rejoins colors split apart at the commas inside their
+// parentheses by comma separated flag parsing, as rgb(255,0,255) would be.
+func JoinColorArgs(parts []string) []string {
+    joined := make([]string, 0, len(parts))
+    depth := 0
+    for _, part := range parts {
+        if depth > 0 {
+            joined[len(joined)-1] += "," + part
+        } else {
+            joined = append(joined, part)
+        }
+        depth += strings.Count(part, "(") - strings.Count(part, ")")
+    }
+    return joined
+}
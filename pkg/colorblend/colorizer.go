@@ -0,0 +1,128 @@
+package colorblend
+
+import (
+    "fmt"
+    "io"
+    "slices"
+    "strings"
+)
+
+// Colorizer colors whole texts with ANSI escapes, spreading the gradient
+// across all of the text it is given.
+type Colorizer struct {
+    cfg *Config
+}
+
+// New returns a Colorizer configured by opts on top of DefaultConfig.
+func New(opts ...Option) (*Colorizer, error) {
+    cfg := newConfig(opts)
+    if err := cfg.validate(); err != nil {
+        return nil, err
+    }
+    return &Colorizer{cfg: cfg}, nil
+}
+
+// validate checks the settings a Colorizer can't recover from while
+// painting.
+func (cfg *Config) validate() error {
+    if !slices.Contains(Colorspaces, cfg.Colorspace) {
+        return fmt.Errorf("unknown colorspace: %s", cfg.Colorspace)
+    }
+    if !slices.Contains(Interpolations, cfg.Interpolation) {
+        return fmt.Errorf("unknown interpolation: %s", cfg.Interpolation)
+    }
+    if cfg.Direction != "horizontal" && cfg.Direction != "vertical" {
+        return fmt.Errorf("unknown direction: %s", cfg.Direction)
+    }
+    if !slices.Contains(Depths, cfg.Depth) {
+        return fmt.Errorf("unknown color depth: %s", cfg.Depth)
+    }
+    if !slices.Contains(DitherModes, cfg.Dither) {
+        return fmt.Errorf("unknown dither mode: %s", cfg.Dither)
+    }
+    if cfg.FinalReset != "" && !slices.Contains(FinalResets, cfg.FinalReset) {
+        return fmt.Errorf("unknown final reset: %s", cfg.FinalReset)
+    }
+    if cfg.SkipWhitespace != "" && cfg.SkipWhitespace != "color" && cfg.SkipWhitespace != "progress" {
+        return fmt.Errorf("unknown whitespace skipping: %s", cfg.SkipWhitespace)
+    }
+    if (cfg.Match != nil || cfg.Exclude != nil) && !slices.Contains(MatchProgresses, cfg.MatchProgress) {
+        return fmt.Errorf("unknown match progress: %s", cfg.MatchProgress)
+    }
+    if cfg.FieldSeparator != "" && !slices.Contains(FieldProgresses, cfg.FieldProgress) {
+        return fmt.Errorf("unknown field progress: %s", cfg.FieldProgress)
+    }
+    if cfg.Target != "" && !slices.Contains(Targets, cfg.Target) {
+        return fmt.Errorf("unknown target: %s", cfg.Target)
+    }
+    if cfg.Fade != "" && !slices.Contains(Fades, cfg.Fade) {
+        return fmt.Errorf("unknown fade: %s", cfg.Fade)
+    }
+    if cfg.Simulate != "" && !slices.Contains(Simulations, cfg.Simulate) {
+        return fmt.Errorf("unknown color vision deficiency: %s", cfg.Simulate)
+    }
+    if cfg.MinLuminance < 0 || cfg.MaxLuminance > 100 || cfg.MinLuminance > cfg.MaxLuminance {
+        return fmt.Errorf("luminance band must be within 0 to 100: %g to %g", cfg.MinLuminance, cfg.MaxLuminance)
+    }
+    if cfg.MinContrast != 0 && (cfg.MinContrast < 1 || cfg.MinContrast > 21) {
+        return fmt.Errorf("minimum contrast must be between 1 and 21: %g", cfg.MinContrast)
+    }
+    if cfg.Steps < 0 {
+        return fmt.Errorf("steps must not be negative: %d", cfg.Steps)
+    }
+    if cfg.Rainbow || cfg.Colormap != "" || cfg.Cubehelix != nil {
+        return nil
+    }
+    if len(cfg.Stops) == 0 {
+        return fmt.Errorf("no color stops")
+    }
+    _, _, err := ParseStopPositions(cfg.Stops)
+    return err
+}
+
+// ColorizeString colors s. Every colored line ends with a reset, and a
+// trailing newline is kept.
+func (z *Colorizer) ColorizeString(s string) string {
+    trailing := strings.HasSuffix(s, "\n")
+    lines := strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+    out := strings.Join(z.ColorizeLines(lines), "\n")
+    if trailing {
+        out += "\n"
+    }
+    return out
+}
+
+// ColorizeLines colors lines as one block, returning them without line
+// breaks.
+func (z *Colorizer) ColorizeLines(lines []string) []string {
+    parsed := make([]Line, len(lines))
+    for i, raw := range lines {
+        parsed[i] = z.cfg.ParseLine(raw)
+    }
+
+    var b strings.Builder
+    a := &ansiRenderer{w: &b, cfg: z.cfg}
+    p := NewPainter(z.cfg, a, parsed)
+    out := make([]string, len(parsed))
+    for i, line := range parsed {
+        // The stops were checked by New, so painting can't fail
+        p.PaintLine(line)
+        out[i] = strings.TrimSuffix(b.String(), "\n")
+        if a.colored {
+            out[i] += "\x1b[0m"
+        }
+        b.Reset()
+        a.colored = false
+        a.held = ""
+    }
+    return out
+}
+
+// ColorizeReader reads all of r and colors it as ColorizeString does.
+func (z *Colorizer) ColorizeReader(r io.Reader) (string, error) {
+    data, err := io.ReadAll(r)
+    if err != nil {
+        return "", err
+    }
+    return z.ColorizeString(string(data)), nil
+}
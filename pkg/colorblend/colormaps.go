@@ -0,0 +1,31 @@
+package colorblend
+
+import (
+    "math"
+
+    "github.com/lucasb-eyer/go-colorful"
+)
+
+// Colormaps are evenly spaced samples of the standard matplotlib
+// perceptually uniform colormaps (and Google's turbo), interpolated
+// linearly in RGB between samples.
+var Colormaps = map[string][]string{
+    "viridis": {"#440154", "#482878", "#3E4A89", "#31688E", "#26828E", "#1F9E89", "#35B779", "#6DCD59", "#B4DE2C", "#FDE725"},
+    "magma":   {"#000004", "#180F3E", "#451077", "#721F81", "#9F2F7F", "#CD4071", "#F1605D", "#FD9567", "#FEC98D", "#FCFDBF"},
+    "inferno": {"#000004", "#1B0C42", "#4B0C6B", "#781C6D", "#A52C60", "#CF4446", "#ED6925", "#FB9A06", "#F7D03C", "#FCFFA4"},
+    "plasma":  {"#0D0887", "#47039F", "#7301A8", "#9C179E", "#BD3786", "#D8576B", "#ED7953", "#FA9E3B", "#FDC926", "#F0F921"},
+    "turbo":   {"#30123B", "#4662D7", "#36AAF9", "#1AE4B6", "#72FE5E", "#C7EF34", "#FABA39", "#F66B19", "#CB2A04", "#7A0403"},
+}
+
+// colormapColor looks progress up in a colormap table.
+func colormapColor(table []string, progress float64) colorful.Color {
+    scaled := math.Max(0, math.Min(progress, 1)) * float64(len(table)-1)
+    i := int(math.Floor(scaled))
+    if i >= len(table)-1 {
+        i = len(table) - 2
+    }
+    // The tables are fixed, known-good hex values
+    c1, _ := colorful.Hex(table[i])
+    c2, _ := colorful.Hex(table[i+1])
+    return c1.BlendRgb(c2, scaled-float64(i))
+}
@@ -0,0 +1,180 @@
+package colorblend
+
+import (
+    "math"
+
+    "github.com/lucasb-eyer/go-colorful"
+)
+
+// colorspace converts colors to and from three components that gradients
+// are interpolated in. hue is the index of a circular component measured
+// in degrees, or -1 when the space has none.
+type colorspace struct {
+    to   func(colorful.Color) [3]float64
+    from func([3]float64) colorful.Color
+    hue  int
+}
+
+// Colorspaces lists the supported colorspaces in the order they are
+// documented.
+var Colorspaces = []string{"hcl", "lab", "rgb", "hsv", "hsl", "oklab", "oklch", "luv", "hsluv", "linear-rgb"}
+
+var colorspaces = map[string]colorspace{
+    "hcl": {
+        to: func(c colorful.Color) [3]float64 {
+            h, chroma, l := c.Hcl()
+            return [3]float64{h, chroma, l}
+        },
+        from: func(v [3]float64) colorful.Color { return colorful.Hcl(v[0], v[1], v[2]) },
+        hue:  0,
+    },
+    "lab": {
+        to: func(c colorful.Color) [3]float64 {
+            l, a, b := c.Lab()
+            return [3]float64{l, a, b}
+        },
+        from: func(v [3]float64) colorful.Color { return colorful.Lab(v[0], v[1], v[2]) },
+        hue:  -1,
+    },
+    "rgb": {
+        to:   func(c colorful.Color) [3]float64 { return [3]float64{c.R, c.G, c.B} },
+        from: func(v [3]float64) colorful.Color { return colorful.Color{R: v[0], G: v[1], B: v[2]} },
+        hue:  -1,
+    },
+    "hsv": {
+        to: func(c colorful.Color) [3]float64 {
+            h, s, v := c.Hsv()
+            return [3]float64{h, s, v}
+        },
+        from: func(v [3]float64) colorful.Color { return colorful.Hsv(v[0], v[1], v[2]) },
+        hue:  0,
+    },
+    "hsl": {
+        to: func(c colorful.Color) [3]float64 {
+            h, s, l := c.Hsl()
+            return [3]float64{h, s, l}
+        },
+        from: func(v [3]float64) colorful.Color { return colorful.Hsl(v[0], v[1], v[2]) },
+        hue:  0,
+    },
+    "oklab": {
+        to: func(c colorful.Color) [3]float64 {
+            l, a, b := toOklab(c)
+            return [3]float64{l, a, b}
+        },
+        from: func(v [3]float64) colorful.Color { return fromOklab(v[0], v[1], v[2]) },
+        hue:  -1,
+    },
+    "oklch": {
+        to: func(c colorful.Color) [3]float64 {
+            l, chroma, h := toOklch(c)
+            return [3]float64{l, chroma, h}
+        },
+        from: func(v [3]float64) colorful.Color { return fromOklch(v[0], v[1], v[2]) },
+        hue:  2,
+    },
+    "luv": {
+        to: func(c colorful.Color) [3]float64 {
+            l, u, v := c.Luv()
+            return [3]float64{l, u, v}
+        },
+        from: func(v [3]float64) colorful.Color { return colorful.Luv(v[0], v[1], v[2]) },
+        hue:  -1,
+    },
+    "hsluv": {
+        to: func(c colorful.Color) [3]float64 {
+            h, s, l := c.HSLuv()
+            return [3]float64{h, s, l}
+        },
+        from: func(v [3]float64) colorful.Color { return colorful.HSLuv(v[0], v[1], v[2]) },
+        hue:  0,
+    },
+    "linear-rgb": linearRGB(0),
+}
+
+// lookupColorspace returns the named colorspace, applying gamma to
+// linear-rgb. Unknown names fall back to hcl.
+func lookupColorspace(name string, gamma float64) colorspace {
+    if name == "linear-rgb" {
+        return linearRGB(gamma)
+    }
+    if cs, ok := colorspaces[name]; ok {
+        return cs
+    }
+    return colorspaces["hcl"]
+}
+
+// linearRGB removes the sRGB transfer curve, or a plain power-law gamma
+// when one is given.
+func linearRGB(gamma float64) colorspace {
+    return colorspace{
+        to: func(c colorful.Color) [3]float64 {
+            if gamma > 0 {
+                return [3]float64{math.Pow(c.R, gamma), math.Pow(c.G, gamma), math.Pow(c.B, gamma)}
+            }
+            r, g, b := c.LinearRgb()
+            return [3]float64{r, g, b}
+        },
+        from: func(v [3]float64) colorful.Color {
+            if gamma > 0 {
+                return colorful.Color{R: math.Pow(v[0], 1/gamma), G: math.Pow(v[1], 1/gamma), B: math.Pow(v[2], 1/gamma)}
+            }
+            return colorful.LinearRgb(v[0], v[1], v[2])
+        },
+        hue: -1,
+    }
+}
+
+// blend interpolates between two colors, moving any hue component around
+// the circle in the requested direction.
+func (cs colorspace) blend(c1, c2 colorful.Color, t float64, hueDirection string) colorful.Color {
+    a, b := cs.to(c1), cs.to(c2)
+    var v [3]float64
+    for i := range v {
+        if i == cs.hue {
+            h1 := math.Mod(a[i]+360, 360)
+            h2 := math.Mod(b[i]+360, 360)
+            v[i] = math.Mod(h1+t*hueDelta(h1, h2, hueDirection)+360, 360)
+            continue
+        }
+        v[i] = a[i] + t*(b[i]-a[i])
+    }
+    return cs.from(v)
+}
+
+// HueDirections lists every accepted hue direction spelling.
+var HueDirections = []string{
+    "shortest", "short", "sh",
+    "longest", "long", "lg",
+    "clockwise", "cw",
+    "counter-clockwise", "counterclockwise", "ccw",
+}
+
+// hueDelta returns the signed angle to travel from h1 to h2 in the given
+// direction. Angles are computed here rather than with go-colorful's
+// blends so every hue-based colorspace follows the same path.
+func hueDelta(h1, h2 float64, hueDirection string) float64 {
+    switch hueDirection {
+    case "clockwise", "cw":
+        return math.Mod(h2-h1+360, 360)
+    case "counter-clockwise", "counterclockwise", "ccw":
+        return -math.Mod(h1-h2+360, 360)
+    }
+
+    deltaH := h2 - h1
+    if deltaH > 180 {
+        deltaH -= 360
+    } else if deltaH < -180 {
+        deltaH += 360
+    }
+    switch hueDirection {
+    case "longest", "long", "lg":
+        // Go the other way around; identical hues make a full turn
+        if deltaH > 0 {
+            deltaH -= 360
+        } else {
+            deltaH += 360
+        }
+    }
+    return deltaH
+}
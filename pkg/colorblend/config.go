@@ -0,0 +1,158 @@
+// Package colorblend colors text with smooth color gradients. A Config
+// describes the gradient and how it is laid over the text, a Painter walks
+// the lines and a Renderer writes the result as terminal escapes or one of
+// several markup formats.
+package colorblend
+
+import (
+    "math/rand"
+    "regexp"
+
+    "github.com/lucasb-eyer/go-colorful"
+)
+
+// Config holds everything that decides how text is colored. Several zero
+// values aren't neutral, so start from DefaultConfig.
+type Config struct {
+    // Color turns coloring on; without it text is written unchanged.
+    Color bool
+
+    // Stops are the hex colors the gradient passes through, used unless
+    // Rainbow, Colormap or Cubehelix selects another source. A stop may
+    // name its position, as in "#FF0000@0.8".
+    Stops         []string
+    Rainbow       bool
+    Colormap      string
+    Cubehelix     *CubehelixParams
+    Colorspace    string
+    Gamma         float64 // power-law gamma for linear-rgb, 0 for sRGB
+    Interpolation string
+    HueDirection  string
+
+    // Direction lays the gradient across each line's cells (horizontal)
+    // or down the lines (vertical).
+    Direction string
+    Easing    func(float64) float64 // nil is linear
+    Invert    bool
+    Steps     int     // discrete color steps, 0 for a smooth gradient
+    Period    float64 // cycle every Period units instead of spanning the text
+    Offset    float64 // shifts the whole gradient, wrapping at 1
+    LineShift float64 // units each line is shifted by, lolcat style
+    PerLine   bool    // restart a horizontal gradient on every line
+
+    // SkipWhitespace leaves spaces and tabs uncolored when "color", and
+    // also keeps them from advancing the gradient when "progress".
+    SkipWhitespace string
+    IgnoreIndent   bool // leave leading whitespace out of the gradient
+
+    // Match, when set, limits coloring to the text it matches on each
+    // line, and Exclude leaves the text it matches uncolored. The gradient
+    // is laid out as MatchProgress says.
+    Match         *regexp.Regexp
+    Exclude       *regexp.Regexp
+    MatchProgress string
+
+    // FieldSeparator, when set, splits lines into CSV style fields and
+    // leaves the separators uncolored. FieldProgress "column" gives every
+    // column its own color of the gradient, "field" runs the gradient
+    // across each field.
+    FieldSeparator string
+    FieldProgress  string
+
+    // JSON lays the gradient over the keys of JSON input only, with
+    // values in fixed colors by type and punctuation uncolored.
+    JSON bool
+
+    // Markdown leaves markup such as heading and list markers uncolored
+    // and code in one color, with every heading spanning the gradient.
+    Markdown bool
+
+    Saturate      float64
+    Brighten      float64
+    Contrast      float64
+    Jitter        float64
+    Rand          *rand.Rand // drives Jitter
+    BlendExisting float64    // how much of the input's own colors to keep
+
+    // Fade blends colors into Background along each line, or down the
+    // lines when vertical, "in" from it, "out" into it or "both", apart
+    // from the gradient.
+    Fade string
+
+    // Simulate shows every color as seen with one of the Simulations, so
+    // a gradient can be checked for how it reads to colorblind users.
+    Simulate string
+
+    // MinLuminance and MaxLuminance keep the CIE L* of every color, 0 to
+    // 100, within a band that reads well on the terminal.
+    MinLuminance float64
+    MaxLuminance float64
+
+    // MinContrast, when above 0, lightens or darkens colors until their
+    // WCAG contrast ratio with Background is at least this, from 1 to 21.
+    MinContrast float64
+
+    // RuneHook, when set, sees every colored cluster with its position in
+    // the block and may replace its character or color.
+    RuneHook func(index int, r rune, c colorful.Color) (rune, colorful.Color)
+
+    // Bold, Italic, Underline, Dim and Reverse add text attributes to
+    // colored text, in terminal output.
+    Bold      bool
+    Italic    bool
+    Underline bool
+    Dim       bool
+    Reverse   bool
+
+    // Target is what the gradient colors in terminal output: the text's
+    // "foreground", or its "underline", leaving the text's own color.
+    Target string
+
+    // FinalReset places the reset of terminal colors: once at the "end",
+    // ahead of the last line ending, on every colored "line", or "none".
+    FinalReset string
+
+    // LineGradient, when set, may pick another gradient for a line, such
+    // as one per log level. It returns nil to keep the configured one.
+    LineGradient func(line Line) *Gradient
+    // LineProgress, when set, may color a whole line from one point of
+    // the gradient, 0 to 1, instead of by its position. It sees the lines
+    // in order, so it may carry a point over to the lines that follow.
+    LineProgress func(line Line) (float64, bool)
+
+    StripANSI bool
+    TabWidth  int
+
+    Depth         string
+    Dither        string
+    Background    string // page, image and MinContrast background, defaulting from Theme
+    Theme         string
+    Font          string
+    IRCColors     int
+    LatexPreamble bool
+}
+
+// DefaultConfig returns the settings colorblend runs with when given no
+// options: magenta to cyan across the text, in truecolor.
+func DefaultConfig() Config {
+    return Config{
+        Color:         true,
+        Stops:         []string{"#FF00FF", "#00FFFF"},
+        Colorspace:    "hcl",
+        Interpolation: "linear",
+        HueDirection:  "shortest",
+        Direction:     "horizontal",
+        Saturate:      1,
+        Contrast:      1,
+        MaxLuminance:  100,
+        Depth:         "truecolor",
+        Dither:        "none",
+        Target:        "foreground",
+        FinalReset:    "end",
+        MatchProgress: "match",
+        FieldProgress: "column",
+        Theme:         "dark",
+        Font:          DefaultFont,
+        IRCColors:     99,
+    }
+}
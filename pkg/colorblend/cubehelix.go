@@ -0,0 +1,71 @@
+package colorblend
+
+import (
+    "fmt"
+    "math"
+    "strconv"
+    "strings"
+
+    "github.com/lucasb-eyer/go-colorful"
+)
+
+// CubehelixParams are the parameters of Green's (2011) cubehelix scheme.
+type CubehelixParams struct {
+    start float64 // starting hue, 0..3 (0 blue, 1 red, 2 green)
+    rot   float64 // rotations through R->G->B over the gradient
+    hue   float64 // saturation amplitude
+    gamma float64 // emphasizes low (<1) or high (>1) intensities
+}
+
+const DefaultCubehelix = "start=0.5,rot=-1.5,hue=1,gamma=1"
+
+// ParseCubehelix reads a comma separated key=value list, with any omitted
+// keys keeping their defaults.
+func ParseCubehelix(spec string) (CubehelixParams, error) {
+    p := CubehelixParams{start: 0.5, rot: -1.5, hue: 1, gamma: 1}
+    for _, field := range strings.Split(spec, ",") {
+        field = strings.TrimSpace(field)
+        if field == "" {
+            continue
+        }
+        key, value, ok := strings.Cut(field, "=")
+        if !ok {
+            return p, fmt.Errorf("expected key=value, got %q", field)
+        }
+        v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+        if err != nil {
+            return p, fmt.Errorf("invalid number for %s: %q", key, value)
+        }
+        switch strings.TrimSpace(key) {
+        case "start", "s":
+            p.start = v
+        case "rot", "rotations", "r":
+            p.rot = v
+        case "hue", "h":
+            p.hue = v
+        case "gamma", "g":
+            p.gamma = v
+        default:
+            return p, fmt.Errorf("unknown parameter %q (expected start, rot, hue or gamma)", key)
+        }
+    }
+    if p.gamma <= 0 {
+        return p, fmt.Errorf("gamma must be positive")
+    }
+    return p, nil
+}
+
+// color returns the cubehelix color at progress. Lightness increases
+// monotonically from black to white while the hue spirals around it.
+func (p CubehelixParams) color(progress float64) colorful.Color {
+    x := math.Max(0, math.Min(progress, 1))
+    fract := math.Pow(x, p.gamma)
+    amp := p.hue * fract * (1 - fract) / 2
+    phi := 2 * math.Pi * (p.start/3 + p.rot*x)
+    cos, sin := math.Cos(phi), math.Sin(phi)
+    return colorful.Color{
+        R: fract + amp*(-0.14861*cos+1.78277*sin),
+        G: fract + amp*(-0.29227*cos-0.90649*sin),
+        B: fract + amp*(1.97294*cos),
+    }.Clamped()
+}
@@ -0,0 +1,127 @@
+package colorblend
+
+import (
+    "fmt"
+    "strconv"
+    "strings"
+
+    "github.com/lucasb-eyer/go-colorful"
+)
+
+// Depths are the supported output color depths.
+var Depths = []string{"truecolor", "256", "16"}
+
+// DitherModes are the supported dithering algorithms.
+var DitherModes = []string{"none", "ordered", "diffusion"}
+
+// Targets are what the gradient can color in terminal output.
+var Targets = []string{"foreground", "underline"}
+
+// FinalResets are the supported placements of the terminal color reset.
+var FinalResets = []string{"end", "line", "none"}
+
+// orderedThresholds is a one dimensional Bayer pattern, applied along the
+// text so neighbouring characters round in different directions.
+var orderedThresholds = [8]float64{0, 4, 2, 6, 1, 5, 3, 7}
+
+// ditherSpread approximates the distance between palette levels, which is
+// how far ordered dithering may push a channel.
+func ditherSpread(depth string) float64 {
+    if depth == "16" {
+        return 0.5
+    }
+    return 0.16
+}
+
+// color formats c as SGR parameters for the Target. Underline colors have
+// no 16 color form, so those use the same palette entries by number.
+func (a *ansiRenderer) color(c colorful.Color) string {
+    sgr := a.foreground(c)
+    if a.cfg.Target != "underline" {
+        return sgr
+    }
+    if strings.HasPrefix(sgr, "38;") {
+        return "58;" + sgr[3:]
+    }
+    n, _ := strconv.Atoi(strings.TrimSuffix(sgr, "m"))
+    if n >= 90 {
+        n -= 90 - 8
+    } else {
+        n -= 30
+    }
+    return "58;5;" + strconv.Itoa(n) + "m"
+}
+
+// colorOff returns the SGR parameters putting the Target's default color
+// back.
+func (a *ansiRenderer) colorOff() string {
+    if a.cfg.Target == "underline" {
+        return "59m"
+    }
+    return "39m"
+}
+
+// foreground formats c as SGR parameters for the selected color depth,
+// quantizing through the xterm palette with optional dithering.
+func (a *ansiRenderer) foreground(c colorful.Color) string {
+    depth := a.cfg.Depth
+    if depth == "truecolor" {
+        return foregroundSGR(c)
+    }
+
+    target := c.Clamped()
+    switch a.cfg.Dither {
+    case "ordered":
+        offset := ((orderedThresholds[a.ditherPos%len(orderedThresholds)]+0.5)/float64(len(orderedThresholds)) - 0.5) * ditherSpread(depth)
+        target = colorful.Color{R: target.R + offset, G: target.G + offset, B: target.B + offset}.Clamped()
+    case "diffusion":
+        target = colorful.Color{R: target.R + a.ditherErr[0], G: target.G + a.ditherErr[1], B: target.B + a.ditherErr[2]}.Clamped()
+    }
+    a.ditherPos++
+
+    n := a.nearestXterm(target)
+
+    if a.cfg.Dither == "diffusion" {
+        // One dimensional error diffusion: the whole rounding error is
+        // carried to the next character
+        q := xterm256(n)
+        a.ditherErr = [3]float64{target.R - q.R, target.G - q.G, target.B - q.B}
+    }
+
+    if depth == "16" {
+        if n < 8 {
+            return fmt.Sprintf("%dm", 30+n)
+        }
+        return fmt.Sprintf("%dm", 90+n-8)
+    }
+    return fmt.Sprintf("38;5;%dm", n)
+}
+
+// maxNearestCache bounds the palette lookups an ansiRenderer remembers.
+const maxNearestCache = 4096
+
+// nearestXterm finds the palette entry for target at the configured
+// depth. Without dithering the same colors come up again and again,
+// especially with Steps, so lookups are remembered.
+func (a *ansiRenderer) nearestXterm(target colorful.Color) int {
+    if n, ok := a.nearest[target]; ok {
+        return n
+    }
+
+    var n int
+    if a.cfg.Depth == "16" {
+        n = nearestXterm(target, 0, 16)
+    } else {
+        // The first 16 entries follow the terminal theme, so only the
+        // cube and gray ramp are reliable
+        n = nearestXterm(target, 16, 256)
+    }
+
+    if a.cfg.Dither == "none" {
+        if a.nearest == nil || len(a.nearest) >= maxNearestCache {
+            a.nearest = make(map[colorful.Color]int)
+        }
+        a.nearest[target] = n
+    }
+    return n
+}
@@ -0,0 +1,130 @@
+package colorblend
+
+import (
+    "fmt"
+    "math"
+    "strconv"
+    "strings"
+)
+
+// Easings lists the built-in easing curves in documented order.
+var Easings = []string{"linear", "ease-in", "ease-out", "ease-in-out", "sine", "quad", "cubic", "expo"}
+
+// easingFuncs reshape progress along the text. sine, quad, cubic and expo are
+// in-out curves of increasing steepness.
+var easingFuncs = map[string]func(float64) float64{
+    "linear":      func(t float64) float64 { return t },
+    "ease-in":     func(t float64) float64 { return t * t },
+    "ease-out":    func(t float64) float64 { return 1 - (1-t)*(1-t) },
+    "ease-in-out": func(t float64) float64 { return t * t * (3 - 2*t) },
+    "sine":        func(t float64) float64 { return (1 - math.Cos(math.Pi*t)) / 2 },
+    "quad": func(t float64) float64 {
+        if t < 0.5 {
+            return 2 * t * t
+        }
+        return 1 - 2*(1-t)*(1-t)
+    },
+    "cubic": func(t float64) float64 {
+        if t < 0.5 {
+            return 4 * t * t * t
+        }
+        return 1 - 4*(1-t)*(1-t)*(1-t)
+    },
+    "expo": func(t float64) float64 {
+        switch {
+        case t <= 0:
+            return 0
+        case t >= 1:
+            return 1
+        case t < 0.5:
+            return math.Pow(2, 20*t-10) / 2
+        default:
+            return 1 - math.Pow(2, -20*t+10)/2
+        }
+    },
+}
+
+// ParseEasing resolves a built-in easing name or a CSS style
+// cubic-bezier(x1,y1,x2,y2) curve.
+func ParseEasing(spec string) (func(float64) float64, error) {
+    if ease, ok := easingFuncs[spec]; ok {
+        return ease, nil
+    }
+    inner, ok := strings.CutPrefix(strings.ReplaceAll(spec, " ", ""), "cubic-bezier(")
+    if !ok || !strings.HasSuffix(inner, ")") {
+        return nil, fmt.Errorf("must be one of: %s, or cubic-bezier(x1,y1,x2,y2)", strings.Join(Easings, ", "))
+    }
+    fields := strings.Split(strings.TrimSuffix(inner, ")"), ",")
+    if len(fields) != 4 {
+        return nil, fmt.Errorf("cubic-bezier takes four numbers")
+    }
+    var p [4]float64
+    for i, field := range fields {
+        v, err := strconv.ParseFloat(field, 64)
+        if err != nil {
+            return nil, fmt.Errorf("invalid cubic-bezier number %q", field)
+        }
+        p[i] = v
+    }
+    // As in CSS the x coordinates must stay within 0..1 so the curve is
+    // a function of progress; y may overshoot.
+    if p[0] < 0 || p[0] > 1 || p[2] < 0 || p[2] > 1 {
+        return nil, fmt.Errorf("cubic-bezier x values must be between 0 and 1")
+    }
+    return cubicBezier(p[0], p[1], p[2], p[3]), nil
+}
+
+// cubicBezier builds the easing for a curve from (0,0) to (1,1) with
+// control points (x1,y1) and (x2,y2), solving x(t) = progress for t with
+// Newton's method and falling back to bisection.
+func cubicBezier(x1, y1, x2, y2 float64) func(float64) float64 {
+    cx := 3 * x1
+    bx := 3*(x2-x1) - cx
+    ax := 1 - cx - bx
+    cy := 3 * y1
+    by := 3*(y2-y1) - cy
+    ay := 1 - cy - by
+
+    sampleX := func(t float64) float64 { return ((ax*t+bx)*t + cx) * t }
+    sampleY := func(t float64) float64 { return ((ay*t+by)*t + cy) * t }
+    slopeX := func(t float64) float64 { return (3*ax*t+2*bx)*t + cx }
+
+    const epsilon = 1e-7
+    return func(x float64) float64 {
+        if x <= 0 || x >= 1 {
+            return x
+        }
+        t := x
+        for i := 0; i < 8; i++ {
+            dx := sampleX(t) - x
+            if math.Abs(dx) < epsilon {
+                return sampleY(t)
+            }
+            d := slopeX(t)
+            if math.Abs(d) < 1e-6 {
+                break
+            }
+            t -= dx / d
+        }
+
+        lo, hi := 0.0, 1.0
+        t = x
+        for lo < hi {
+            dx := sampleX(t) - x
+            if math.Abs(dx) < epsilon {
+                break
+            }
+            if dx > 0 {
+                hi = t
+            } else {
+                lo = t
+            }
+            next := (lo + hi) / 2
+            if next == t {
+                break
+            }
+            t = next
+        }
+        return sampleY(t)
+    }
+}
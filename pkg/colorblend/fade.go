@@ -0,0 +1,39 @@
+package colorblend
+
+import (
+    "math"
+
+    "github.com/lucasb-eyer/go-colorful"
+)
+
+// Fades lists the ways Fade can blend text into the background.
+var Fades = []string{"in", "out", "both"}
+
+// fade blends c toward the background by where it sits: the cell at
+// column of a line width cells wide, or the line when the gradient is
+// vertical. Text fades in from the background, out into it, or both,
+// whatever the gradient does.
+func (p *Painter) fade(c colorful.Color, column, width int) colorful.Color {
+    cfg := p.cfg
+    bg, err := colorful.Hex(cfg.pageBackground())
+    if err != nil {
+        return c
+    }
+    at := 0.0
+    switch {
+    case cfg.Direction == "vertical" && p.total > 1:
+        at = math.Min(float64(p.lineIndex)/float64(p.total-1), 1)
+    case cfg.Direction == "horizontal" && width > 1:
+        at = float64(column) / float64(width-1)
+    }
+    amount := 0.0
+    switch cfg.Fade {
+    case "in":
+        amount = 1 - at
+    case "out":
+        amount = at
+    case "both":
+        amount = math.Abs(2*at - 1)
+    }
+    return c.BlendLab(bg, amount).Clamped()
+}
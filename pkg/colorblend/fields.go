@@ -0,0 +1,32 @@
+package colorblend
+
+// FieldProgresses are the supported ways of laying the gradient over
+// fields: one color per "column", or across every "field" on its own.
+var FieldProgresses = []string{"column", "field"}
+
+// fieldSpans splits a line into FieldSeparator separated fields, CSV
+// style, with separators inside double quotes kept in their field. spans
+// holds the field each segment is in, or -1 for the separators and
+// escapes, and widths the cells of every field.
+func (cfg *Config) fieldSpans(line []segment) (spans []int, widths []int) {
+    spans = make([]int, len(line))
+    widths = []int{0}
+    quoted := false
+    for i, seg := range line {
+        spans[i] = -1
+        if seg.isEscape() {
+            continue
+        }
+        if seg.text == "\"" {
+            quoted = !quoted
+        } else if !quoted && seg.text == cfg.FieldSeparator {
+            widths = append(widths, 0)
+            continue
+        }
+        spans[i] = len(widths) - 1
+        if cfg.SkipWhitespace != "progress" || !isWhitespace(seg.text) {
+            widths[len(widths)-1] += seg.width
+        }
+    }
+    return spans, widths
+}
@@ -0,0 +1,200 @@
+// Package figlet renders large text with FIGlet fonts, so banners can be
+// colored without an external figlet. Characters are laid out at full
+// width or fitted together (kerned) as the font asks, but never smushed.
+package figlet
+
+import (
+    "bufio"
+    "embed"
+    "fmt"
+    "io"
+    "os"
+    "path"
+    "sort"
+    "strconv"
+    "strings"
+    "unicode/utf8"
+)
+
+//go:embed fonts/*.flf
+var builtin embed.FS
+
+// Font is a parsed FIGlet font.
+type Font struct {
+    height    int
+    hardblank rune
+    fitted    bool // kern characters together rather than use full width
+    glyphs    map[rune][]string
+}
+
+// Fonts returns the names of the embedded fonts.
+func Fonts() []string {
+    entries, _ := builtin.ReadDir("fonts")
+    names := make([]string, 0, len(entries))
+    for _, entry := range entries {
+        names = append(names, strings.TrimSuffix(entry.Name(), ".flf"))
+    }
+    sort.Strings(names)
+    return names
+}
+
+// Load returns the embedded font called name, or else reads name as the
+// path of a .flf file.
+func Load(name string) (*Font, error) {
+    if f, err := builtin.Open(path.Join("fonts", name+".flf")); err == nil {
+        defer f.Close()
+        return Parse(f)
+    }
+    f, err := os.Open(name)
+    if err != nil {
+        return nil, err
+    }
+    defer f.Close()
+    font, err := Parse(f)
+    if err != nil {
+        return nil, fmt.Errorf("%s: %w", name, err)
+    }
+    return font, nil
+}
+
+// deutsch are the characters every FIGlet font defines after ASCII.
+var deutsch = []rune{'Ä', 'Ö', 'Ü', 'ä', 'ö', 'ü', 'ß'}
+
+// Parse reads a font in the FIGlet .flf format: the ASCII characters,
+// the Deutsch ones when present and then any code tagged characters.
+func Parse(r io.Reader) (*Font, error) {
+    scanner := bufio.NewScanner(r)
+    scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+    if !scanner.Scan() {
+        return nil, fmt.Errorf("empty font")
+    }
+    header := strings.Fields(scanner.Text())
+    if len(header) < 6 || !strings.HasPrefix(header[0], "flf2a") || len(header[0]) < 6 {
+        return nil, fmt.Errorf("not a FIGlet font")
+    }
+    hardblank, _ := utf8.DecodeRuneInString(header[0][5:])
+    height, err := strconv.Atoi(header[1])
+    if err != nil || height < 1 {
+        return nil, fmt.Errorf("invalid height: %s", header[1])
+    }
+    layout, err := strconv.Atoi(header[4])
+    if err != nil {
+        return nil, fmt.Errorf("invalid layout: %s", header[4])
+    }
+    comments, err := strconv.Atoi(header[5])
+    if err != nil || comments < 0 {
+        return nil, fmt.Errorf("invalid comment line count: %s", header[5])
+    }
+    for i := 0; i < comments; i++ {
+        if !scanner.Scan() {
+            return nil, fmt.Errorf("font ends in its comments")
+        }
+    }
+
+    font := &Font{height: height, hardblank: hardblank, fitted: layout >= 0, glyphs: make(map[rune][]string)}
+    glyph := func() ([]string, bool) {
+        rows := make([]string, height)
+        for i := range rows {
+            if !scanner.Scan() {
+                return nil, false
+            }
+            // Rows end with one endmark, the last row with two
+            row := strings.TrimRight(scanner.Text(), " ")
+            if row != "" {
+                end := row[len(row)-1:]
+                row = strings.TrimRight(row, end)
+            }
+            rows[i] = row
+        }
+        return rows, true
+    }
+    for c := rune(' '); c <= '~'; c++ {
+        rows, ok := glyph()
+        if !ok {
+            return nil, fmt.Errorf("font ends before %q", c)
+        }
+        font.glyphs[c] = rows
+    }
+    for _, c := range deutsch {
+        rows, ok := glyph()
+        if !ok {
+            return font, scanner.Err()
+        }
+        font.glyphs[c] = rows
+    }
+    for scanner.Scan() {
+        // A code tag line, such as "0x00C6  LATIN CAPITAL LETTER AE"
+        fields := strings.Fields(scanner.Text())
+        if len(fields) == 0 {
+            continue
+        }
+        code, err := strconv.ParseInt(fields[0], 0, 32)
+        if err != nil {
+            return nil, fmt.Errorf("invalid code tag: %s", fields[0])
+        }
+        rows, ok := glyph()
+        if !ok {
+            return nil, fmt.Errorf("font ends in character %s", fields[0])
+        }
+        if code >= 0 {
+            font.glyphs[rune(code)] = rows
+        }
+    }
+    return font, scanner.Err()
+}
+
+// Height is the number of lines every rendered line of text takes.
+func (f *Font) Height() int {
+    return f.height
+}
+
+// Render lays text out in the font, one banner of Height rows for every
+// line of text. Characters the font lacks are left out.
+func (f *Font) Render(text string) []string {
+    var out []string
+    for _, line := range strings.Split(text, "\n") {
+        rows := make([][]rune, f.height)
+        for _, c := range line {
+            glyph, ok := f.glyphs[c]
+            if !ok {
+                continue
+            }
+            f.fit(rows, glyph)
+        }
+        for _, row := range rows {
+            out = append(out, strings.TrimRight(strings.ReplaceAll(string(row), string(f.hardblank), " "), " "))
+        }
+    }
+    return out
+}
+
+// fit appends a glyph to rows. In fitted fonts it moves left as far as
+// it goes with only blanks overlapping. Hardblanks are not blanks, so they keep
+// characters apart.
+func (f *Font) fit(rows [][]rune, glyph []string) {
+    shift := -1
+    if !f.fitted {
+        shift = 0
+    }
+    for i, row := range rows {
+        g := []rune(glyph[i])
+        blanks := trailingBlanks(row) + len(g) - len([]rune(strings.TrimLeft(glyph[i], " ")))
+        if shift < 0 || blanks < shift {
+            shift = blanks
+        }
+    }
+    for i, row := range rows {
+        g := []rune(glyph[i])
+        cut := min(shift, trailingBlanks(row))
+        rows[i] = append(row[:len(row)-cut], g[min(shift-cut, len(g)):]...)
+    }
+}
+
+// trailingBlanks counts the spaces a row ends with.
+func trailingBlanks(row []rune) int {
+    n := 0
+    for n < len(row) && row[len(row)-1-n] == ' ' {
+        n++
+    }
+    return n
+}
@@ -0,0 +1,348 @@
+package colorblend
+
+import (
+    "fmt"
+    "html"
+    "io"
+    "net/url"
+    "slices"
+    "strings"
+
+    "github.com/lucasb-eyer/go-colorful"
+)
+
+// Themes are the page colors of each theme, as background and text color
+// for uncolored text.
+var Themes = map[string][2]string{
+    "dark":  {"#1E1E1E", "#D4D4D4"},
+    "light": {"#FFFFFF", "#1E1E1E"},
+}
+
+// DefaultFont is the monospace font stack used by page formats.
+const DefaultFont = "ui-monospace, 'SF Mono', Menlo, Consolas, 'DejaVu Sans Mono', monospace"
+
+// pageBackground returns Background, falling back to the theme.
+func (cfg *Config) pageBackground() string {
+    if cfg.Background != "" {
+        return cfg.Background
+    }
+    return Themes[cfg.Theme][0]
+}
+
+// textColor returns the theme's color for uncolored text.
+func (cfg *Config) textColor() string {
+    return Themes[cfg.Theme][1]
+}
+
+// run is a stretch of text sharing one color. hex is empty for text
+// written without color, and link holds the URI of an OSC 8 hyperlink in
+// the input around it.
+type run struct {
+    hex  string
+    text string
+    link string
+}
+
+// appendRun adds text in color c to runs, merging it into the last run
+// when the color and link are the same.
+func appendRun(runs []run, c *colorful.Color, text, link string) []run {
+    if text == "" {
+        return runs
+    }
+    hex := ""
+    if c != nil {
+        hex = strings.ToUpper(c.Clamped().Hex())
+    }
+    if n := len(runs); n > 0 && runs[n-1].hex == hex && runs[n-1].link == link {
+        runs[n-1].text += text
+        return runs
+    }
+    return append(runs, run{hex: hex, text: text, link: link})
+}
+
+// lineRenderer writes every line as soon as it ends, formatting its merged
+// runs with line. footer is written by Flush.
+type lineRenderer struct {
+    w      io.Writer
+    runs   []run
+    line   func(b *strings.Builder, runs []run)
+    footer string
+    link   string // the input's hyperlink open here, which may span lines
+}
+
+func (l *lineRenderer) RenderRun(c *colorful.Color, text string) {
+    l.runs = appendRun(l.runs, c, text, l.link)
+}
+
+// RenderEscape follows the input's hyperlinks, so formats that have links
+// can keep them. Every other escape is dropped.
+func (l *lineRenderer) RenderEscape(seq string) {
+    if uri, ok := hyperlink(seq); ok {
+        l.link = uri
+    }
+}
+
+func (l *lineRenderer) LineBreak() {
+    var b strings.Builder
+    l.line(&b, l.runs)
+    b.WriteByte('\n')
+    io.WriteString(l.w, b.String())
+    l.runs = l.runs[:0]
+}
+
+func (l *lineRenderer) Flush() error {
+    if len(l.runs) > 0 {
+        l.LineBreak()
+    }
+    _, err := io.WriteString(l.w, l.footer)
+    return err
+}
+
+// documentRenderer collects every line for formats with a header that
+// depends on all of them, such as the size of an image, and writes the
+// whole document on Flush.
+type documentRenderer struct {
+    w     io.Writer
+    cfg   *Config
+    lines [][]run
+    runs  []run
+    write func(w io.Writer, cfg *Config, lines [][]run) error
+}
+
+func (d *documentRenderer) RenderRun(c *colorful.Color, text string) {
+    d.runs = appendRun(d.runs, c, text, "")
+}
+
+func (d *documentRenderer) LineBreak() {
+    d.lines = append(d.lines, d.runs)
+    d.runs = nil
+}
+
+func (d *documentRenderer) Flush() error {
+    if len(d.runs) > 0 {
+        d.LineBreak()
+    }
+    return d.write(d.w, d.cfg, d.lines)
+}
+
+// markup describes a format that wraps each colored run in a span and
+// escapes all text. Formats whose spans set a color without closing it
+// give the directive restoring the default color as uncolor, written
+// before uncolored text that follows a color, and reset, appended to
+// lines that end colored. Formats with links set link to wrap the runs
+// of each of the input's hyperlinks.
+type markup struct {
+    escape  func(text string) string
+    span    func(hex, text string) string
+    link    func(uri, text string) string
+    uncolor string
+    reset   string
+}
+
+func (m markup) line(b *strings.Builder, runs []run) {
+    painted := false
+    for i := 0; i < len(runs); {
+        // Runs sharing a link are wrapped in it together
+        j := i + 1
+        if m.link != nil && runs[i].link != "" {
+            for j < len(runs) && runs[j].link == runs[i].link {
+                j++
+            }
+        }
+        var spans strings.Builder
+        for _, r := range runs[i:j] {
+            text := r.text
+            if m.escape != nil {
+                text = m.escape(text)
+            }
+            if r.hex == "" {
+                // Whitespace looks the same in any color
+                if painted && m.uncolor != "" && strings.Trim(r.text, " \t") != "" {
+                    spans.WriteString(m.uncolor)
+                    painted = false
+                }
+                spans.WriteString(text)
+                continue
+            }
+            spans.WriteString(m.span(r.hex, text))
+            painted = true
+        }
+        if m.link != nil && linkable(runs[i].link) {
+            b.WriteString(m.link(runs[i].link, spans.String()))
+        } else {
+            b.WriteString(spans.String())
+        }
+        i = j
+    }
+    if painted {
+        b.WriteString(m.reset)
+    }
+}
+
+// linkSchemes are the URI schemes of the input's hyperlinks that markup
+// keeps as links. Others, such as javascript: and data:, could run or
+// show anything where the markup ends up, so their text is left unlinked.
+var linkSchemes = []string{"http", "https", "file", "mailto"}
+
+func linkable(uri string) bool {
+    u, err := url.Parse(uri)
+    return err == nil && slices.Contains(linkSchemes, strings.ToLower(u.Scheme))
+}
+
+var htmlMarkup = markup{
+    escape: html.EscapeString,
+    span: func(hex, text string) string {
+        return fmt.Sprintf(`<span style="color:%s">%s</span>`, hex, text)
+    },
+    link: func(uri, text string) string {
+        return fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(uri), text)
+    },
+}
+
+func newHTMLRenderer(w io.Writer, cfg *Config) Renderer {
+    return &lineRenderer{w: w, line: htmlMarkup.line}
+}
+
+// newHTMLPageRenderer writes a complete document, with the text in a
+// <pre> block styled from the Theme, Background and Font.
+func newHTMLPageRenderer(w io.Writer, cfg *Config) Renderer {
+    fmt.Fprintln(w, "<!DOCTYPE html>")
+    fmt.Fprintln(w, "<html>")
+    fmt.Fprintln(w, "<head>")
+    fmt.Fprintln(w, `<meta charset="utf-8">`)
+    fmt.Fprintln(w, "<title>colorblend</title>")
+    fmt.Fprintln(w, "</head>")
+    fmt.Fprintf(w, "<body style=\"margin:0;background:%s\">\n", html.EscapeString(cfg.pageBackground()))
+    fmt.Fprintf(w, "<pre style=\"margin:0;padding:1em;color:%s;font-family:%s\">", cfg.textColor(), html.EscapeString(cfg.Font))
+    return &lineRenderer{w: w, line: htmlMarkup.line, footer: "</pre>\n</body>\n</html>\n"}
+}
+
+func newPangoRenderer(w io.Writer, cfg *Config) Renderer {
+    m := markup{
+        escape: html.EscapeString,
+        span: func(hex, text string) string {
+            return fmt.Sprintf(`<span foreground="%s">%s</span>`, hex, text)
+        },
+    }
+    return &lineRenderer{w: w, line: m.line}
+}
+
+func newBBCodeRenderer(w io.Writer, cfg *Config) Renderer {
+    m := markup{
+        span: func(hex, text string) string {
+            return fmt.Sprintf("[color=%s]%s[/color]", hex, text)
+        },
+        link: func(uri, text string) string {
+            // A ] would end the tag early
+            uri = strings.NewReplacer("[", "%5B", "]", "%5D").Replace(uri)
+            return fmt.Sprintf("[url=%s]%s[/url]", uri, text)
+        },
+    }
+    return &lineRenderer{w: w, line: m.line}
+}
+
+func newTmuxRenderer(w io.Writer, cfg *Config) Renderer {
+    m := markup{
+        // A lone # would start a tmux format
+        escape: func(text string) string { return strings.ReplaceAll(text, "#", "##") },
+        span: func(hex, text string) string {
+            return fmt.Sprintf("#[fg=%s]%s", hex, text)
+        },
+        uncolor: "#[default]",
+        reset:   "#[default]",
+    }
+    return &lineRenderer{w: w, line: m.line}
+}
+
+func newZshRenderer(w io.Writer, cfg *Config) Renderer {
+    m := markup{
+        // % starts a prompt escape
+        escape: func(text string) string { return strings.ReplaceAll(text, "%", "%%") },
+        span: func(hex, text string) string {
+            return fmt.Sprintf("%%F{%s}%s", hex, text)
+        },
+        uncolor: "%f",
+        reset:   "%f",
+    }
+    return &lineRenderer{w: w, line: m.line}
+}
+
+// bashPromptEscaper keeps text literal in PS1. The prompt's backslash
+// escapes are decoded before promptvars expands it, so $ and backticks
+// need a backslash left over from decoding, and backslashes themselves
+// one more to survive both.
+var bashPromptEscaper = strings.NewReplacer(`\`, `\\\\`, "$", `\\$`, "`", "\\\\`")
+
+func newBashPromptRenderer(w io.Writer, cfg *Config) Renderer {
+    m := markup{
+        escape: bashPromptEscaper.Replace,
+        // Escapes are hidden from bash's width count inside \[ \]
+        span: func(hex, text string) string {
+            c, _ := colorful.Hex(hex)
+            return fmt.Sprintf(`\[\e[%s\]%s`, foregroundSGR(c), text)
+        },
+        uncolor: `\[\e[39m\]`,
+        reset:   `\[\e[0m\]`,
+    }
+    return &lineRenderer{w: w, line: m.line}
+}
+
+// newFishRenderer writes each line as fish commands, for sourcing from
+// fish_prompt. Lines after the first start with a newline, so the last
+// line leaves the cursor where the prompt continues.
+func newFishRenderer(w io.Writer, cfg *Config) Renderer {
+    lines := 0
+    return &lineRenderer{w: w, line: func(b *strings.Builder, runs []run) {
+        if lines > 0 {
+            b.WriteString("echo; ")
+        }
+        lines++
+        for _, r := range runs {
+            if r.hex != "" {
+                fmt.Fprintf(b, "set_color %s; ", strings.TrimPrefix(r.hex, "#"))
+            }
+            fmt.Fprintf(b, "printf %%s %s; ", fishQuote(r.text))
+        }
+        b.WriteString("set_color normal")
+    }}
+}
+
+// fishQuote single quotes s for fish, which only treats \ and ' as special
+// inside single quotes.
+func fishQuote(s string) string {
+    s = strings.ReplaceAll(s, `\`, `\\`)
+    return "'" + strings.ReplaceAll(s, "'", `\'`) + "'"
+}
+
+func newIRCRenderer(w io.Writer, cfg *Config) Renderer {
+    return &lineRenderer{w: w, line: func(b *strings.Builder, runs []run) {
+        writeIRCRuns(b, runs, cfg.IRCColors)
+    }}
+}
+
+// newLatexRenderer writes xcolor runs, wrapped in a minimal document with
+// LatexPreamble.
+func newLatexRenderer(w io.Writer, cfg *Config) Renderer {
+    l := &lineRenderer{w: w, line: writeLatexRuns}
+    if cfg.LatexPreamble {
+        fmt.Fprintln(w, `\documentclass{article}`)
+        fmt.Fprintln(w, `\usepackage[T1]{fontenc}`)
+        fmt.Fprintln(w, `\usepackage{xcolor}`)
+        fmt.Fprintln(w, `\begin{document}`)
+        fmt.Fprintln(w, `\noindent{\ttfamily`)
+        l.footer = "}\n\\end{document}\n"
+    }
+    return l
+}
+
+func newSVGRenderer(w io.Writer, cfg *Config) Renderer {
+    return &documentRenderer{w: w, cfg: cfg, write: writeSVG}
+}
+
+func newPNGRenderer(w io.Writer, cfg *Config) Renderer {
+    return &documentRenderer{w: w, cfg: cfg, write: writePNG}
+}
+
+func newRTFRenderer(w io.Writer, cfg *Config) Renderer {
+    return &documentRenderer{w: w, cfg: cfg, write: writeRTF}
+}
@@ -0,0 +1,232 @@
+package colorblend
+
+import (
+    "fmt"
+    "math"
+    "strconv"
+    "strings"
+
+    "github.com/lucasb-eyer/go-colorful"
+)
+
+// ColorAt returns the color at progress 0..1 of the configured gradient
+// source, with the color adjustments applied.
+func (cfg *Config) ColorAt(progress float64) (colorful.Color, error) {
+    c, err := cfg.sourceColorAt(progress)
+    if err != nil {
+        return c, err
+    }
+    return cfg.finalColor(cfg.adjust(c)), nil
+}
+
+// sourceColorAt returns the unadjusted color of the gradient source.
+func (cfg *Config) sourceColorAt(progress float64) (colorful.Color, error) {
+    if cfg.Rainbow {
+        return rainbowColor(progress, cfg.HueDirection), nil
+    }
+    if cfg.Colormap != "" {
+        return colormapColor(Colormaps[cfg.Colormap], progress), nil
+    }
+    if cfg.Cubehelix != nil {
+        return cfg.Cubehelix.color(progress), nil
+    }
+    g, err := cfg.Gradient()
+    if err != nil {
+        return colorful.Color{}, err
+    }
+    return g.At(progress), nil
+}
+
+// Gradient returns the gradient through the configured Stops. Easing is
+// left unset, since a Painter eases progress for every gradient source.
+func (cfg *Config) Gradient() (*Gradient, error) {
+    stops, positions, err := ParseStopPositions(cfg.Stops)
+    if err != nil {
+        return nil, err
+    }
+    return &Gradient{
+        Stops:         stops,
+        Positions:     positions,
+        Colorspace:    cfg.Colorspace,
+        Gamma:         cfg.Gamma,
+        Interpolation: cfg.Interpolation,
+        HueDirection:  cfg.HueDirection,
+    }, nil
+}
+
+// ParseStops parses color stops such as "#FF00FF", in any of the forms
+// ParseColor accepts.
+func ParseStops(hexes []string) ([]colorful.Color, error) {
+    stops := make([]colorful.Color, len(hexes))
+    for i, hex := range hexes {
+        stop, err := ParseColor(hex)
+        if err != nil {
+            return nil, err
+        }
+        stops[i] = stop
+    }
+    return stops, nil
+}
+
+// ParseStopPositions parses color stops that may each be given a place
+// along the gradient, from 0 to 1 or as a percentage, as in "#FF0000@0.8"
+// or "#FF0000@80%". positions is nil when no stop has one. Otherwise, as
+// in CSS, the first and last stops default to 0 and 1 and the others are
+// spread evenly between the positioned stops around them.
+func ParseStopPositions(specs []string) (stops []colorful.Color, positions []float64, err error) {
+    colors := make([]string, len(specs))
+    placed := make([]bool, len(specs))
+    positions = make([]float64, len(specs))
+    positioned := false
+    for i, spec := range specs {
+        color, at, found := strings.Cut(spec, "@")
+        colors[i] = color
+        if !found {
+            continue
+        }
+        scale := 1.0
+        if strings.HasSuffix(at, "%") {
+            at, scale = strings.TrimSuffix(at, "%"), 100
+        }
+        pos, err := strconv.ParseFloat(at, 64)
+        if err != nil || pos < 0 || pos > scale {
+            return nil, nil, fmt.Errorf("invalid stop position: %s (must be 0 to 1, or 0%% to 100%%)", spec)
+        }
+        positions[i], placed[i], positioned = pos/scale, true, true
+    }
+    stops, err = ParseStops(colors)
+    if err != nil || !positioned {
+        return stops, nil, err
+    }
+
+    n := len(specs)
+    if !placed[0] {
+        positions[0], placed[0] = 0, true
+    }
+    if !placed[n-1] {
+        positions[n-1], placed[n-1] = 1, true
+    }
+    for i := 0; i < n; {
+        j := i + 1
+        for j < n && !placed[j] {
+            j++
+        }
+        if j == n {
+            break
+        }
+        if positions[j] < positions[i] {
+            return nil, nil, fmt.Errorf("stop positions must not decrease: %s comes after %s", specs[j], specs[i])
+        }
+        for k := i + 1; k < j; k++ {
+            positions[k] = positions[i] + (positions[j]-positions[i])*float64(k-i)/float64(j-i)
+        }
+        i = j
+    }
+    return stops, positions, nil
+}
+
+// Gradient blends through color stops, evenly spaced unless Positions
+// places them. Empty Colorspace, Interpolation and HueDirection mean hcl,
+// linear and shortest, and a nil Easing is linear.
+type Gradient struct {
+    Stops         []colorful.Color
+    Positions     []float64 // where each stop sits, 0 to 1 and never decreasing
+    Colorspace    string
+    Gamma         float64 // power-law gamma for linear-rgb, 0 for sRGB
+    Interpolation string
+    HueDirection  string
+    Easing        func(float64) float64
+}
+
+// NewGradient returns a gradient through stops with the default
+// colorspace, interpolation and hue direction.
+func NewGradient(stops ...colorful.Color) *Gradient {
+    return &Gradient{Stops: stops}
+}
+
+// At returns the color at t, from 0 at the first stop to 1 at the last.
+func (g *Gradient) At(t float64) colorful.Color {
+    stops := g.Stops
+    switch len(stops) {
+    case 0:
+        return colorful.Color{}
+    case 1:
+        return stops[0]
+    }
+    if g.Easing != nil {
+        t = g.Easing(t)
+    }
+    if len(g.Positions) == len(stops) {
+        t = g.place(t)
+    }
+
+    space := lookupColorspace(g.Colorspace, g.Gamma)
+    if len(stops) > 2 {
+        switch g.Interpolation {
+        case "bezier":
+            return bezierColor(space, stops, t, g.HueDirection)
+        case "spline":
+            return splineColor(space, stops, t, g.HueDirection)
+        }
+    }
+
+    // Stops are evenly spaced; find the pair t falls between
+    scaled := t * float64(len(stops)-1)
+    i := int(math.Floor(scaled))
+    if i >= len(stops)-1 {
+        i = len(stops) - 2
+    }
+    if i < 0 {
+        i = 0
+    }
+
+    // Interpolate in the chosen colorspace with directional hue
+    return space.blend(stops[i], stops[i+1], scaled-float64(i), g.HueDirection)
+}
+
+// place maps t onto the evenly spaced stops, from where it falls between
+// the positioned ones.
+func (g *Gradient) place(t float64) float64 {
+    pos := g.Positions
+    n := len(pos)
+    if t <= pos[0] {
+        return 0
+    }
+    if t >= pos[n-1] {
+        return 1
+    }
+    i := 0
+    for i < n-2 && t >= pos[i+1] {
+        i++
+    }
+    return (float64(i) + (t-pos[i])/(pos[i+1]-pos[i])) / float64(n-1)
+}
+
+// Colors returns a palette of n colors evenly spaced along the gradient,
+// including both ends.
+func (g *Gradient) Colors(n int) []colorful.Color {
+    if n <= 0 {
+        return nil
+    }
+    colors := make([]colorful.Color, n)
+    for i := range colors {
+        t := 0.0
+        if n > 1 {
+            t = float64(i) / float64(n-1)
+        }
+        colors[i] = g.At(t)
+    }
+    return colors
+}
+
+// rainbowColor sweeps the full HSV hue circle, clockwise unless asked to
+// run counter-clockwise. Shortest and longest make no difference to a full
+// turn.
+func rainbowColor(progress float64, hueDirection string) colorful.Color {
+    h := progress * 360
+    switch hueDirection {
+    case "counter-clockwise", "counterclockwise", "ccw":
+        h = 360 - h
+    }
+    return colorful.Hsv(math.Mod(h, 360), 1, 1)
+}
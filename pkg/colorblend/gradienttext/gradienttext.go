@@ -0,0 +1,111 @@
+// Package gradienttext is a Bubble Tea component that shows text in a
+// colorblend gradient, either still or flowing across the text.
+package gradienttext
+
+import (
+    "math"
+    "strings"
+    "sync/atomic"
+    "time"
+
+    tea "github.com/charmbracelet/bubbletea"
+    "github.com/charmbracelet/lipgloss"
+    "github.com/ocodo/colorblend/pkg/colorblend"
+    "github.com/ocodo/colorblend/pkg/colorblend/lipglossx"
+    "github.com/rivo/uniseg"
+)
+
+// lastID numbers models so each only acts on its own ticks.
+var lastID int64
+
+// TickMsg advances the animation of the model with the matching ID.
+type TickMsg struct {
+    ID   int
+    Time time.Time
+}
+
+// Model renders Text through Gradient. With an FPS above zero the
+// gradient shifts by Speed cycles per second, wrapping round the text.
+type Model struct {
+    Text     string
+    Gradient *colorblend.Gradient
+    Style    lipgloss.Style // base style, its foreground is replaced
+    FPS      int
+    Speed    float64
+
+    id    int
+    phase float64
+}
+
+// New returns a still model; set FPS and Speed to animate it.
+func New(text string, g *colorblend.Gradient) Model {
+    return Model{
+        Text:     text,
+        Gradient: g,
+        Style:    lipgloss.NewStyle(),
+        Speed:    0.5,
+        id:       int(atomic.AddInt64(&lastID, 1)),
+    }
+}
+
+// ID identifies the model's ticks.
+func (m Model) ID() int {
+    return m.id
+}
+
+// Init starts the animation when FPS is set.
+func (m Model) Init() tea.Cmd {
+    return m.tick()
+}
+
+// Update advances the phase on the model's own ticks.
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+    tick, ok := msg.(TickMsg)
+    if !ok || tick.ID != m.id || m.FPS <= 0 {
+        return m, nil
+    }
+    m.phase = math.Mod(m.phase+m.Speed/float64(m.FPS), 1)
+    if m.phase < 0 {
+        m.phase++
+    }
+    return m, m.tick()
+}
+
+func (m Model) tick() tea.Cmd {
+    if m.FPS <= 0 {
+        return nil
+    }
+    id := m.id
+    return tea.Tick(time.Second/time.Duration(m.FPS), func(t time.Time) tea.Msg {
+        return TickMsg{ID: id, Time: t}
+    })
+}
+
+// View renders the text. A still model matches lipglossx.Render; an
+// animated one cycles the gradient over the width of the widest line.
+func (m Model) View() string {
+    if m.FPS <= 0 {
+        return lipglossx.Render(m.Style, m.Gradient, m.Text)
+    }
+
+    lines := strings.Split(m.Text, "\n")
+    width := 0
+    for _, line := range lines {
+        width = max(width, uniseg.StringWidth(line))
+    }
+
+    var b strings.Builder
+    for i, line := range lines {
+        if i > 0 {
+            b.WriteByte('\n')
+        }
+        column := 0
+        graphemes := uniseg.NewGraphemes(line)
+        for graphemes.Next() {
+            t := math.Mod(float64(column)/float64(width)+m.phase, 1)
+            b.WriteString(m.Style.Foreground(lipglossx.Color(m.Gradient.At(t))).Render(graphemes.Str()))
+            column += graphemes.Width()
+        }
+    }
+    return b.String()
+}
@@ -0,0 +1,76 @@
+package colorblend
+
+import (
+    "math"
+
+    "github.com/lucasb-eyer/go-colorful"
+)
+
+// Interpolations lists the ways a gradient can pass through its stops.
+var Interpolations = []string{"linear", "bezier", "spline"}
+
+// stopCoords converts stops into colorspace coordinates, unwrapping any
+// hue component so consecutive stops differ by the angle hueDirection
+// asks for. Curves can then treat every component as a plain number.
+func stopCoords(cs colorspace, stops []colorful.Color, hueDirection string) [][3]float64 {
+    coords := make([][3]float64, len(stops))
+    for i, stop := range stops {
+        coords[i] = cs.to(stop)
+        if cs.hue < 0 {
+            continue
+        }
+        h := math.Mod(coords[i][cs.hue]+360, 360)
+        if i > 0 {
+            prev := coords[i-1][cs.hue]
+            h = prev + hueDelta(math.Mod(prev+360, 360), h, hueDirection)
+        }
+        coords[i][cs.hue] = h
+    }
+    return coords
+}
+
+// fromCoords folds a hue back onto the circle and converts to a color.
+func fromCoords(cs colorspace, v [3]float64) colorful.Color {
+    if cs.hue >= 0 {
+        v[cs.hue] = math.Mod(math.Mod(v[cs.hue], 360)+360, 360)
+    }
+    return cs.from(v)
+}
+
+// bezierColor evaluates the Bézier curve whose control points are the
+// stops, using De Casteljau's algorithm. The curve starts and ends on the
+// first and last stops and is pulled towards the ones in between.
+func bezierColor(cs colorspace, stops []colorful.Color, t float64, hueDirection string) colorful.Color {
+    points := stopCoords(cs, stops, hueDirection)
+    for n := len(points) - 1; n > 0; n-- {
+        for i := 0; i < n; i++ {
+            for c := range points[i] {
+                points[i][c] += t * (points[i+1][c] - points[i][c])
+            }
+        }
+    }
+    return fromCoords(cs, points[0])
+}
+
+// splineColor evaluates a uniform Catmull-Rom spline that passes exactly
+// through every evenly spaced stop with a continuous tangent, so there are
+// no corners at the stops. The end stops are repeated as phantom points.
+func splineColor(cs colorspace, stops []colorful.Color, t float64, hueDirection string) colorful.Color {
+    points := stopCoords(cs, stops, hueDirection)
+    scaled := math.Max(0, math.Min(t, 1)) * float64(len(points)-1)
+    i := int(math.Floor(scaled))
+    if i >= len(points)-1 {
+        i = len(points) - 2
+    }
+    u := scaled - float64(i)
+
+    p0, p1, p2, p3 := points[max(i-1, 0)], points[i], points[i+1], points[min(i+2, len(points)-1)]
+    var v [3]float64
+    for c := range v {
+        v[c] = 0.5 * (2*p1[c] +
+            (-p0[c]+p2[c])*u +
+            (2*p0[c]-5*p1[c]+4*p2[c]-p3[c])*u*u +
+            (-p0[c]+3*p1[c]-3*p2[c]+p3[c])*u*u*u)
+    }
+    return fromCoords(cs, v)
+}
@@ -0,0 +1,62 @@
+package colorblend
+
+import (
+    "fmt"
+    "strings"
+
+    "github.com/lucasb-eyer/go-colorful"
+)
+
+// mircColors are the RGB values of the mIRC color codes: the 16 standard
+// colors followed by the 83 of the extended 99 color range.
+var mircColors = [99]string{
+    "#FFFFFF", "#000000", "#00007F", "#009300", "#FF0000", "#7F0000", "#9C009C", "#FC7F00",
+    "#FFFF00", "#00FC00", "#009393", "#00FFFF", "#0000FC", "#FF00FF", "#7F7F7F", "#D2D2D2",
+    "#470000", "#472100", "#474700", "#324700", "#004700", "#00472C", "#004747", "#002747", "#000047", "#2E0047", "#470047", "#47002A",
+    "#740000", "#743A00", "#747400", "#517400", "#007400", "#007449", "#007474", "#004074", "#000074", "#4B0074", "#740074", "#740045",
+    "#B50000", "#B56300", "#B5B500", "#7DB500", "#00B500", "#00B571", "#00B5B5", "#0063B5", "#0000B5", "#7500B5", "#B500B5", "#B5006B",
+    "#FF0000", "#FF8C00", "#FFFF00", "#B2FF00", "#00FF00", "#00FFA0", "#00FFFF", "#008CFF", "#0000FF", "#A500FF", "#FF00FF", "#FF0098",
+    "#FF5959", "#FFB459", "#FFFF71", "#CFFF60", "#6FFF6F", "#65FFC9", "#6DFFFF", "#59B4FF", "#5959FF", "#C459FF", "#FF66FF", "#FF59BC",
+    "#FF9C9C", "#FFD39C", "#FFFF9C", "#E2FF9C", "#9CFF9C", "#9CFFDB", "#9CFFFF", "#9CD3FF", "#9C9CFF", "#DC9CFF", "#FF9CFF", "#FF94D3",
+    "#000000", "#131313", "#282828", "#363636", "#4D4D4D", "#656565", "#818181", "#9F9F9F", "#BCBCBC", "#E2E2E2", "#FFFFFF",
+}
+
+// nearestMirc returns the mIRC color code closest to hex, searching only
+// the standard 16 colors unless palette is 99.
+func nearestMirc(hex string, palette int) int {
+    c, _ := colorful.Hex(hex)
+    best, bestDist := 0, -1.0
+    for n, m := range mircColors[:palette] {
+        p, _ := colorful.Hex(m)
+        if d := c.DistanceLab(p); bestDist < 0 || d < bestDist {
+            best, bestDist = n, d
+        }
+    }
+    return best
+}
+
+// writeIRCRuns formats a line with mIRC color codes. Codes are always two
+// digits so text starting with a digit isn't read as part of the code.
+func writeIRCRuns(b *strings.Builder, runs []run, palette int) {
+    last := -1
+    for _, r := range runs {
+        if r.hex == "" {
+            // \x0f rather than a bare \x03, which would take digits
+            // starting the text as a color
+            if last >= 0 && strings.Trim(r.text, " \t") != "" {
+                b.WriteString("\x0f")
+                last = -1
+            }
+            b.WriteString(r.text)
+            continue
+        }
+        if code := nearestMirc(r.hex, palette); code != last {
+            fmt.Fprintf(b, "\x03%02d", code)
+            last = code
+        }
+        b.WriteString(r.text)
+    }
+    if last >= 0 {
+        b.WriteString("\x0f")
+    }
+}
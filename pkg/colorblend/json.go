@@ -0,0 +1,95 @@
+package colorblend
+
+import (
+    "strings"
+
+    "github.com/lucasb-eyer/go-colorful"
+)
+
+// jsonKind is the kind of JSON token a cluster belongs to.
+type jsonKind int
+
+const (
+    jsonOther jsonKind = iota // whitespace, punctuation and anything else
+    jsonKey
+    jsonString
+    jsonNumber
+    jsonLiteral
+)
+
+// jsonColors are the fixed colors of JSON values, which keep the same
+// color wherever they are so only the keys carry the gradient.
+var jsonColors = map[jsonKind]colorful.Color{
+    jsonString:  mustHex("#A6E3A1"),
+    jsonNumber:  mustHex("#FAB387"),
+    jsonLiteral: mustHex("#CBA6F7"),
+}
+
+func mustHex(hex string) colorful.Color {
+    c, err := colorful.Hex(hex)
+    if err != nil {
+        panic(err)
+    }
+    return c
+}
+
+// oneOf reports whether text is a single one of the bytes in set. The
+// empty text of a line's missing ending is none of them.
+func oneOf(text, set string) bool {
+    return len(text) == 1 && strings.Contains(set, text)
+}
+
+// jsonKinds tokenizes one line of JSON, giving the kind of every
+// segment, or nil unless JSON is set. A string is a key when a colon
+// follows it on the same line, as it does in compact and indented JSON.
+func (cfg *Config) jsonKinds(line []segment) []jsonKind {
+    if !cfg.JSON {
+        return nil
+    }
+    kinds := make([]jsonKind, len(line))
+    for i := 0; i < len(line); i++ {
+        seg := line[i]
+        switch {
+        case seg.isEscape():
+        case seg.text == `"`:
+            // Find the closing quote, skipping escaped characters
+            end := i + 1
+            for ; end < len(line); end++ {
+                if line[end].text == `\` {
+                    end++
+                } else if line[end].text == `"` {
+                    break
+                }
+            }
+            end = min(end, len(line)-1)
+            kind := jsonString
+            for next := end + 1; next < len(line); next++ {
+                if line[next].isEscape() || isWhitespace(line[next].text) {
+                    continue
+                }
+                if line[next].text == ":" {
+                    kind = jsonKey
+                }
+                break
+            }
+            for j := i; j <= end; j++ {
+                if !line[j].isEscape() {
+                    kinds[j] = kind
+                }
+            }
+            i = end
+        case oneOf(seg.text, "-0123456789"):
+            end := i
+            for ; end < len(line) && oneOf(line[end].text, "-+.0123456789eE"); end++ {
+                kinds[end] = jsonNumber
+            }
+            i = end - 1
+        case seg.text >= "a" && seg.text <= "z":
+            for ; i < len(line) && line[i].text >= "a" && line[i].text <= "z"; i++ {
+                kinds[i] = jsonLiteral
+            }
+            i--
+        }
+    }
+    return kinds
+}
@@ -0,0 +1,37 @@
+package colorblend
+
+import (
+    "fmt"
+    "strings"
+)
+
+// latexEscapes maps characters special to LaTeX onto their escaped form.
+// Spaces become ties so indentation and runs of spaces survive.
+var latexEscapes = strings.NewReplacer(
+    `\`, `\textbackslash{}`,
+    `{`, `\{`,
+    `}`, `\}`,
+    `$`, `\$`,
+    `&`, `\&`,
+    `#`, `\#`,
+    `_`, `\_`,
+    `%`, `\%`,
+    `~`, `\textasciitilde{}`,
+    `^`, `\textasciicircum{}`,
+    ` `, `~`,
+)
+
+// writeLatexRuns formats a line as xcolor \textcolor runs. The \mbox{}
+// keeps \\ valid on empty lines.
+func writeLatexRuns(b *strings.Builder, runs []run) {
+    b.WriteString(`\mbox{}`)
+    for _, r := range runs {
+        text := latexEscapes.Replace(r.text)
+        if r.hex == "" {
+            b.WriteString(text)
+            continue
+        }
+        fmt.Fprintf(b, `\textcolor[HTML]{%s}{%s}`, strings.TrimPrefix(r.hex, "#"), text)
+    }
+    b.WriteString(`\\`)
+}
@@ -0,0 +1,59 @@
+// Package lipglossx uses colorblend gradients with Lip Gloss styles, so
+// Bubble Tea and Lip Gloss programs can share colorblend's blending.
+package lipglossx
+
+import (
+    "strings"
+
+    "github.com/charmbracelet/lipgloss"
+    "github.com/lucasb-eyer/go-colorful"
+    "github.com/ocodo/colorblend/pkg/colorblend"
+    "github.com/rivo/uniseg"
+)
+
+// Styles returns n copies of base, each with its foreground set to the
+// next of n colors evenly spaced along g.
+func Styles(base lipgloss.Style, g *colorblend.Gradient, n int) []lipgloss.Style {
+    colors := g.Colors(n)
+    styles := make([]lipgloss.Style, len(colors))
+    for i, c := range colors {
+        styles[i] = base.Foreground(Color(c))
+    }
+    return styles
+}
+
+// Color converts a blended color to a Lip Gloss color.
+func Color(c colorful.Color) lipgloss.Color {
+    return lipgloss.Color(c.Clamped().Hex())
+}
+
+// Render styles text with base and lays g across it from left to right.
+// Every line uses the same columns, spread over the widest line, so
+// gradients stay aligned down a block of text.
+func Render(base lipgloss.Style, g *colorblend.Gradient, text string) string {
+    lines := strings.Split(text, "\n")
+    width := 0
+    for _, line := range lines {
+        width = max(width, uniseg.StringWidth(line))
+    }
+    styles := Styles(base, g, width)
+
+    var b strings.Builder
+    for i, line := range lines {
+        if i > 0 {
+            b.WriteByte('\n')
+        }
+        column := 0
+        graphemes := uniseg.NewGraphemes(line)
+        for graphemes.Next() {
+            cluster := graphemes.Str()
+            if column < len(styles) {
+                b.WriteString(styles[column].Render(cluster))
+            } else {
+                b.WriteString(base.Render(cluster))
+            }
+            column += graphemes.Width()
+        }
+    }
+    return b.String()
+}
@@ -0,0 +1,147 @@
+package colorblend
+
+import (
+    "strings"
+    "unicode"
+    "unicode/utf8"
+)
+
+// markdownKind is the part of a Markdown document a cluster belongs to.
+type markdownKind int
+
+const (
+    markdownText   markdownKind = iota
+    markdownMarker              // heading, list, quote and emphasis markup
+    markdownCode                // code blocks and inline code
+)
+
+// markdownCodeColor is the single color code is shown in.
+var markdownCodeColor = mustHex("#A6ADC8")
+
+// markdownKinds classifies one line of Markdown, tracking code fences
+// across lines, and reports whether it is a heading. It returns nil
+// unless Markdown is set.
+func (p *Painter) markdownKinds(line []segment) ([]markdownKind, bool) {
+    if !p.cfg.Markdown {
+        return nil, false
+    }
+    kinds := make([]markdownKind, len(line))
+    text := strings.TrimLeft(plainText(line), " ")
+
+    // Fenced code blocks, including their fences, are all code
+    if p.fence != "" || strings.HasPrefix(text, "```") || strings.HasPrefix(text, "~~~") {
+        switch {
+        case p.fence == "":
+            p.fence = text[:3]
+        case strings.HasPrefix(text, p.fence):
+            p.fence = ""
+        }
+        for i := range kinds {
+            kinds[i] = markdownCode
+        }
+        return kinds, false
+    }
+
+    // Block markers at the start of the line: headings, quotes and list
+    // bullets or numbers, each with the space after them
+    i := skipSpaces(line, 0)
+    heading := false
+    for again := true; again; {
+        again = false
+        start := i
+        switch {
+        case i < len(line) && line[i].text == "#":
+            // Up to six #s, then a space or nothing
+            end := i
+            for end < len(line) && line[end].text == "#" {
+                end++
+            }
+            if end-i <= 6 && (end == len(line) || line[end].text == " ") {
+                i, heading = end, true
+            }
+        case i < len(line) && line[i].text == ">":
+            i++
+            again = true
+        case i+1 < len(line) && oneOf(line[i].text, "-*+") && line[i+1].text == " ":
+            i++
+        default:
+            for i < len(line) && line[i].text >= "0" && line[i].text <= "9" {
+                i++
+            }
+            if i == start || i >= len(line) || (line[i].text != "." && line[i].text != ")") {
+                i = start
+            } else {
+                i++
+            }
+        }
+        if i > start {
+            i = skipSpaces(line, i)
+        }
+        for j := start; j < i; j++ {
+            kinds[j] = markdownMarker
+        }
+    }
+
+    // Inline code and emphasis
+    for ; i < len(line); i++ {
+        switch t := line[i].text; {
+        case t == "`":
+            end := i + 1
+            for end < len(line) && line[end].text != "`" {
+                end++
+            }
+            if end == len(line) {
+                continue
+            }
+            for j := i; j <= end; j++ {
+                kinds[j] = markdownCode
+            }
+            i = end
+        case t == "*" || t == "_":
+            if emphasisMarker(line, i) {
+                kinds[i] = markdownMarker
+            }
+        }
+    }
+    return kinds, heading
+}
+
+// emphasisMarker reports whether the * or _ at i marks emphasis: it
+// touches a word on only one side, or doubles another marker, so
+// snake_case names and a lone 2 * 3 are left as text.
+func emphasisMarker(line []segment, i int) bool {
+    word := func(j int) bool {
+        if j < 0 || j >= len(line) || line[j].isEscape() {
+            return false
+        }
+        r, _ := utf8.DecodeRuneInString(line[j].text)
+        return unicode.IsLetter(r) || unicode.IsDigit(r)
+    }
+    same := func(j int) bool {
+        return j >= 0 && j < len(line) && line[j].text == line[i].text
+    }
+    if same(i-1) || same(i+1) {
+        return true
+    }
+    return word(i-1) != word(i+1)
+}
+
+// skipSpaces returns the index of the first segment from i that isn't a
+// space.
+func skipSpaces(line []segment, i int) int {
+    for i < len(line) && line[i].text == " " {
+        i++
+    }
+    return i
+}
+
+// plainText is a line's text without its escapes.
+func plainText(line []segment) string {
+    var b strings.Builder
+    for _, seg := range line {
+        if !seg.isEscape() {
+            b.WriteString(seg.text)
+        }
+    }
+    return b.String()
+}
@@ -0,0 +1,92 @@
+package colorblend
+
+import (
+    "regexp"
+    "strings"
+)
+
+// MatchProgresses are the supported ways of laying the gradient over the
+// text selected by Match and Exclude: across every colored stretch on its
+// own ("match"), or "global" across the whole text with only the selected
+// text colored.
+var MatchProgresses = []string{"match", "global"}
+
+// lineSpans divides a line into the stretches colored on their own, by
+// fields or by matches, as described by fieldSpans and matchSpans.
+func (cfg *Config) lineSpans(line []segment) (spans []int, widths []int) {
+    if cfg.FieldSeparator != "" {
+        return cfg.fieldSpans(line)
+    }
+    return cfg.matchSpans(line)
+}
+
+// matchSpans finds the stretches of a line that Match selects and Exclude
+// doesn't. spans holds the stretch each segment belongs to, or -1 for
+// segments left uncolored, and widths the cells of every stretch. Both
+// are nil when neither is set.
+func (cfg *Config) matchSpans(line []segment) (spans []int, widths []int) {
+    if cfg.Match == nil && cfg.Exclude == nil {
+        return nil, nil
+    }
+
+    // Match against the text alone, so escapes in the input can't split
+    // a match
+    var b strings.Builder
+    offsets := make([]int, len(line))
+    for i, seg := range line {
+        offsets[i] = b.Len()
+        if !seg.isEscape() {
+            b.WriteString(seg.text)
+        }
+    }
+    matched := matchIndexes(cfg.Match, b.String(), offsets)
+    excluded := matchIndexes(cfg.Exclude, b.String(), offsets)
+
+    spans = make([]int, len(line))
+    last, gap := -1, false
+    for i, seg := range line {
+        spans[i] = -1
+        if seg.isEscape() {
+            continue
+        }
+        m := 0
+        if matched != nil {
+            m = matched[i]
+        }
+        if m < 0 || (excluded != nil && excluded[i] >= 0) {
+            gap = true
+            continue
+        }
+        // Each match, and each stretch between exclusions, is its own
+        if len(widths) == 0 || gap || m != last {
+            widths = append(widths, 0)
+            last, gap = m, false
+        }
+        spans[i] = len(widths) - 1
+        if cfg.SkipWhitespace != "progress" || !isWhitespace(seg.text) {
+            widths[len(widths)-1] += seg.width
+        }
+    }
+    return spans, widths
+}
+
+// matchIndexes returns, for each segment starting at offsets in text, the
+// index of the match of re it falls in, or -1. It is nil when re is.
+func matchIndexes(re *regexp.Regexp, text string, offsets []int) []int {
+    if re == nil {
+        return nil
+    }
+    matches := re.FindAllStringIndex(text, -1)
+    indexes := make([]int, len(offsets))
+    m := 0
+    for i, offset := range offsets {
+        for m < len(matches) && offset >= matches[m][1] {
+            m++
+        }
+        indexes[i] = -1
+        if m < len(matches) && offset >= matches[m][0] {
+            indexes[i] = m
+        }
+    }
+    return indexes
+}
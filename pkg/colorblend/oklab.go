@@ -0,0 +1,43 @@
+package colorblend
+
+import (
+    "math"
+
+    "github.com/lucasb-eyer/go-colorful"
+)
+
+// OKLab conversions from Björn Ottosson's reference implementation;
+// go-colorful does not provide them.
+
+func toOklab(c colorful.Color) (l, a, b float64) {
+    r, g, bl := c.LinearRgb()
+    lc := math.Cbrt(0.4122214708*r + 0.5363325363*g + 0.0514459929*bl)
+    mc := math.Cbrt(0.2119034982*r + 0.6806995451*g + 0.1073969566*bl)
+    sc := math.Cbrt(0.0883024619*r + 0.2817188376*g + 0.6299787005*bl)
+    return 0.2104542553*lc + 0.7936177850*mc - 0.0040720468*sc,
+        1.9779984951*lc - 2.4285922050*mc + 0.4505937099*sc,
+        0.0259040371*lc + 0.7827717662*mc - 0.8086757660*sc
+}
+
+func fromOklab(l, a, b float64) colorful.Color {
+    lc := l + 0.3963377774*a + 0.2158037573*b
+    mc := l - 0.1055613458*a - 0.0638541728*b
+    sc := l - 0.0894841775*a - 1.2914855480*b
+    lc, mc, sc = lc*lc*lc, mc*mc*mc, sc*sc*sc
+    return colorful.LinearRgb(
+        4.0767416621*lc-3.3077115913*mc+0.2309699292*sc,
+        -1.2684380046*lc+2.6097574011*mc-0.3413193965*sc,
+        -0.0041960863*lc-0.7034186147*mc+1.7076147010*sc,
+    )
+}
+
+func toOklch(c colorful.Color) (l, chroma, h float64) {
+    l, a, b := toOklab(c)
+    h = math.Atan2(b, a) * 180 / math.Pi
+    return l, math.Hypot(a, b), math.Mod(h+360, 360)
+}
+
+func fromOklch(l, chroma, h float64) colorful.Color {
+    rad := h * math.Pi / 180
+    return fromOklab(l, chroma*math.Cos(rad), chroma*math.Sin(rad))
+}
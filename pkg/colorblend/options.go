@@ -0,0 +1,66 @@
+package colorblend
+
+import (
+    "github.com/lucasb-eyer/go-colorful"
+)
+
+// Option adjusts the Config a Colorizer or Writer is built with.
+type Option func(*Config)
+
+// WithConfig starts from cfg instead of DefaultConfig. Later options are
+// applied on top of it.
+func WithConfig(cfg Config) Option {
+    return func(c *Config) {
+        *c = cfg
+    }
+}
+
+// WithStops sets the hex colors the gradient passes through, which may
+// carry positions as ParseStopPositions reads them.
+func WithStops(hexes ...string) Option {
+    return func(c *Config) {
+        c.Stops = append([]string(nil), hexes...)
+    }
+}
+
+// WithColorspace sets the colorspace the stops are blended in, one of
+// Colorspaces.
+func WithColorspace(name string) Option {
+    return func(c *Config) {
+        c.Colorspace = name
+    }
+}
+
+// WithDirection lays the gradient across each line ("horizontal") or down
+// the lines ("vertical").
+func WithDirection(direction string) Option {
+    return func(c *Config) {
+        c.Direction = direction
+    }
+}
+
+// WithSteps quantizes the gradient into n discrete colors, or keeps it
+// smooth when n is 0.
+func WithSteps(n int) Option {
+    return func(c *Config) {
+        c.Steps = n
+    }
+}
+
+// WithRuneHook lets hook override the character or color at each position
+// of the text. index counts the colored clusters from the start of the
+// text.
+func WithRuneHook(hook func(index int, r rune, c colorful.Color) (rune, colorful.Color)) Option {
+    return func(c *Config) {
+        c.RuneHook = hook
+    }
+}
+
+// newConfig applies opts to the default configuration.
+func newConfig(opts []Option) *Config {
+    cfg := DefaultConfig()
+    for _, opt := range opts {
+        opt(&cfg)
+    }
+    return &cfg
+}
@@ -0,0 +1,113 @@
+package colorblend
+
+import (
+    "bytes"
+)
+
+// parallelChunk is how many lines a worker colors at a time.
+const parallelChunk = 512
+
+// PaintLinesParallel colors a block like PaintLines, but spreads the
+// lines of large blocks over workers goroutines and writes their output
+// in order. Only the ANSI renderer is split up. Jitter, BlendExisting,
+// RuneHook, LineProgress and Markdown depend on the order lines are
+// colored in, and the text Match, Exclude, FieldSeparator and JSON leave
+// uncolored on the color before it, so with any of them set the block is
+// painted by PaintLines.
+func PaintLinesParallel(cfg *Config, out Renderer, lines []Line, workers int) error {
+    a, ok := out.(*ansiRenderer)
+    if !ok || workers < 2 || len(lines) < 2*parallelChunk || !cfg.Color ||
+        cfg.Jitter > 0 || cfg.BlendExisting > 0 || cfg.RuneHook != nil || cfg.LineProgress != nil ||
+        cfg.Match != nil || cfg.Exclude != nil || cfg.FieldSeparator != "" || cfg.JSON || cfg.Markdown {
+        return PaintLines(cfg, out, lines)
+    }
+
+    // Start the gradient once so its stops and palette are shared by
+    // every worker rather than built by each of them
+    base := NewPainter(cfg, out, lines)
+    if _, err := base.colorAt(0); err != nil {
+        return err
+    }
+
+    // Work out where along the gradient each chunk starts
+    type chunk struct {
+        lines  []Line
+        start  Painter
+        result chan chunkResult
+    }
+    var chunks []*chunk
+    cell := 0
+    for i := 0; i < len(lines); i += parallelChunk {
+        c := &chunk{lines: lines[i:min(i+parallelChunk, len(lines))], start: *base, result: make(chan chunkResult, 1)}
+        c.start.lineIndex = i
+        c.start.cell = cell
+        for _, line := range c.lines {
+            cell += cfg.Cells(line)
+        }
+        chunks = append(chunks, c)
+    }
+
+    jobs := make(chan *chunk)
+    done := make(chan struct{})
+    defer close(done)
+    // At most two chunks per worker are held, colored but not yet written
+    pending := make(chan struct{}, 2*workers)
+    go func() {
+        defer close(jobs)
+        for _, c := range chunks {
+            select {
+            case pending <- struct{}{}:
+            case <-done:
+                return
+            }
+            select {
+            case jobs <- c:
+            case <-done:
+                return
+            }
+        }
+    }()
+    for i := 0; i < workers; i++ {
+        go func() {
+            for c := range jobs {
+                c.result <- paintChunk(cfg, c.start, c.lines)
+            }
+        }()
+    }
+
+    for _, c := range chunks {
+        r := <-c.result
+        if r.err != nil {
+            return r.err
+        }
+        a.write("")
+        if _, err := a.w.Write(r.out); err != nil {
+            return err
+        }
+        a.colored = a.colored || r.colored
+        a.held = r.held
+        <-pending
+    }
+    return nil
+}
+
+// chunkResult is the output of one chunk of lines.
+type chunkResult struct {
+    out     []byte
+    colored bool
+    held    string // the chunk's last line ending, not yet in out
+    err     error
+}
+
+// paintChunk colors lines into a buffer, carrying on from p's position.
+func paintChunk(cfg *Config, p Painter, lines []Line) chunkResult {
+    var buf bytes.Buffer
+    r := &ansiRenderer{w: &buf, cfg: cfg}
+    p.out = r
+    for _, line := range lines {
+        if err := p.PaintLine(line); err != nil {
+            return chunkResult{err: err}
+        }
+    }
+    return chunkResult{out: buf.Bytes(), colored: r.colored, held: r.held}
+}
@@ -0,0 +1,83 @@
+package colorblend
+
+import (
+    "image"
+    "image/color"
+    "image/draw"
+    "image/png"
+    "io"
+
+    "github.com/lucasb-eyer/go-colorful"
+    "github.com/rivo/uniseg"
+    "golang.org/x/image/font"
+    "golang.org/x/image/font/gofont/gomono"
+    "golang.org/x/image/font/opentype"
+    "golang.org/x/image/math/fixed"
+)
+
+// pngFontSize is the size of the embedded Go Mono font used by --format
+// png, in pixels.
+const pngFontSize = 16
+
+// writePNG rasterizes lines onto a Background colored
+// image and writes it as a PNG.
+func writePNG(w io.Writer, cfg *Config, lines [][]run) error {
+    parsed, err := opentype.Parse(gomono.TTF)
+    if err != nil {
+        return err
+    }
+    face, err := opentype.NewFace(parsed, &opentype.FaceOptions{Size: pngFontSize, DPI: 72, Hinting: font.HintingFull})
+    if err != nil {
+        return err
+    }
+    defer face.Close()
+
+    metrics := face.Metrics()
+    cellWidth, _ := face.GlyphAdvance('M')
+    lineHeight := metrics.Height.Ceil()
+    padding := pngFontSize
+
+    cols := 0
+    for _, runs := range lines {
+        width := 0
+        for _, r := range runs {
+            width += uniseg.StringWidth(r.text)
+        }
+        cols = max(cols, width)
+    }
+    width := (cellWidth*fixed.Int26_6(cols)).Ceil() + 2*padding
+    height := lineHeight*len(lines) + 2*padding
+
+    img := image.NewRGBA(image.Rect(0, 0, width, height))
+    bg, _ := colorful.Hex(cfg.pageBackground())
+    draw.Draw(img, img.Bounds(), &image.Uniform{toRGBA(bg)}, image.Point{}, draw.Src)
+
+    text, _ := colorful.Hex(cfg.textColor())
+    d := &font.Drawer{Dst: img, Face: face}
+    for i, runs := range lines {
+        y := fixed.I(padding + i*lineHeight + metrics.Ascent.Ceil())
+        cell := 0
+        for _, r := range runs {
+            c := text
+            if r.hex != "" {
+                c, _ = colorful.Hex(r.hex)
+            }
+            d.Src = &image.Uniform{toRGBA(c)}
+            // Place every cluster on the cell grid, so wide characters
+            // and glyphs missing from the font keep later text aligned
+            g := uniseg.NewGraphemes(r.text)
+            for g.Next() {
+                d.Dot = fixed.Point26_6{X: fixed.I(padding) + cellWidth*fixed.Int26_6(cell), Y: y}
+                d.DrawString(g.Str())
+                cell += g.Width()
+            }
+        }
+    }
+
+    return png.Encode(w, img)
+}
+
+func toRGBA(c colorful.Color) color.RGBA {
+    r, g, b := c.Clamped().RGB255()
+    return color.RGBA{R: r, G: g, B: b, A: 0xff}
+}
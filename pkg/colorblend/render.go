@@ -0,0 +1,390 @@
+package colorblend
+
+import (
+    "math"
+    "unicode/utf8"
+
+    "github.com/lucasb-eyer/go-colorful"
+)
+
+// Painter walks lines of input, tracking the position along the gradient,
+// and hands the colored text to a Renderer. A block can be painted all at
+// once, or line by line as it is read when the gradient doesn't depend on
+// its size.
+type Painter struct {
+    cfg       *Config
+    out       Renderer
+    total     int // gradient units in the block, 0 when not known up front
+    lineIndex int
+    cell      int
+    index     int // clusters colored so far, for RuneHook
+    existing  inputColor
+    gradient  *Gradient        // Stops, parsed on first use
+    override  *Gradient        // this line's LineGradient
+    fence     string           // the Markdown code fence we're inside
+    fixed     bool             // this line has a LineProgress
+    fixedAt   float64
+    palette   []colorful.Color // every step's color when Steps is set
+
+    // Phase shifts the whole gradient, wrapping at 1. It starts at the
+    // configured Offset.
+    Phase float64
+}
+
+// NewPainter prepares a Painter for a block. lines may be nil when
+// streaming, in which case the progress mode must not need the total:
+// set Period or PerLine, or give the total with SetTotal.
+func NewPainter(cfg *Config, out Renderer, lines []Line) *Painter {
+    p := &Painter{cfg: cfg, out: out, Phase: cfg.Offset}
+    if cfg.Direction == "horizontal" {
+        for _, line := range lines {
+            p.total += cfg.Cells(line)
+        }
+    } else {
+        p.total = len(lines)
+    }
+    return p
+}
+
+// SetTotal sets the size of the block in gradient units, cells for a
+// horizontal gradient and lines for a vertical one, so a block can be
+// streamed without reading it all first.
+func (p *Painter) SetTotal(units int) {
+    p.total = units
+}
+
+// Cells returns how many cells of line a horizontal gradient advances
+// over, leaving out whitespace when SkipWhitespace is "progress", the
+// indentation with IgnoreIndent and everything but the keys with JSON.
+func (cfg *Config) Cells(line Line) int {
+    if cfg.SkipWhitespace != "progress" && !cfg.IgnoreIndent && !cfg.JSON {
+        return visibleWidth(line)
+    }
+    n := 0
+    kinds := cfg.jsonKinds(line)
+    indent := cfg.IgnoreIndent
+    for i, seg := range line {
+        if seg.isEscape() || (kinds != nil && kinds[i] != jsonKey) {
+            continue
+        }
+        if isWhitespace(seg.text) && (indent || cfg.SkipWhitespace == "progress") {
+            continue
+        }
+        indent = false
+        n += seg.width
+    }
+    return n
+}
+
+// isWhitespace reports whether a cluster is a space or a tab.
+func isWhitespace(text string) bool {
+    return text == " " || text == "\t"
+}
+
+// progress maps a gradient unit (a cell or a line) onto 0..1, cycling
+// every Period units when set.
+func (p *Painter) progress(unit float64) float64 {
+    return p.progressOver(unit, p.total)
+}
+
+// progressOver is progress for a gradient spanning total units rather
+// than the whole block.
+func (p *Painter) progressOver(unit float64, total int) float64 {
+    cfg := p.cfg
+    progress := 0.0
+    if cfg.Period > 0 {
+        progress = math.Mod(unit, cfg.Period) / cfg.Period
+    } else if total > 1 {
+        progress = math.Min(unit/float64(total-1), 1)
+    }
+    return p.shape(progress)
+}
+
+// shape applies Phase, Easing, Invert and Steps to a raw progress.
+func (p *Painter) shape(progress float64) float64 {
+    cfg := p.cfg
+    if p.Phase != 0 {
+        progress = math.Mod(progress+p.Phase, 1)
+        if progress < 0 {
+            progress++
+        }
+    }
+    if cfg.Easing != nil {
+        progress = cfg.Easing(progress)
+    }
+    if cfg.Invert {
+        progress = 1.0 - progress
+    }
+    if cfg.Steps > 0 {
+        progress = math.Round(progress*float64(cfg.Steps)) / float64(cfg.Steps)
+    }
+    return progress
+}
+
+// colorAt is Config.ColorAt without the per call work: the stops are
+// parsed once, and a stepped gradient's few colors are all computed on
+// first use.
+func (p *Painter) colorAt(progress float64) (colorful.Color, error) {
+    cfg := p.cfg
+    if p.override != nil {
+        return cfg.adjust(p.override.At(progress)), nil
+    }
+    if cfg.Steps <= 0 {
+        c, err := p.sourceColorAt(progress)
+        if err != nil {
+            return c, err
+        }
+        return cfg.adjust(c), nil
+    }
+
+    if p.palette == nil {
+        palette := make([]colorful.Color, cfg.Steps+1)
+        for i := range palette {
+            c, err := p.sourceColorAt(float64(i) / float64(cfg.Steps))
+            if err != nil {
+                return c, err
+            }
+            palette[i] = cfg.adjust(c)
+        }
+        p.palette = palette
+    }
+    i := int(math.Round(progress * float64(cfg.Steps)))
+    return p.palette[max(0, min(i, cfg.Steps))], nil
+}
+
+// sourceColorAt is Config.sourceColorAt with the stops parsed only once.
+func (p *Painter) sourceColorAt(progress float64) (colorful.Color, error) {
+    cfg := p.cfg
+    if cfg.Rainbow || cfg.Colormap != "" || cfg.Cubehelix != nil {
+        return cfg.sourceColorAt(progress)
+    }
+    if p.gradient == nil {
+        g, err := cfg.Gradient()
+        if err != nil {
+            return colorful.Color{}, err
+        }
+        p.gradient = g
+    }
+    return p.gradient.At(progress), nil
+}
+
+// escape passes one of the input's escapes on to renderers that keep them.
+func (p *Painter) escape(seq string) {
+    if e, ok := p.out.(escapeRenderer); ok {
+        e.RenderEscape(seq)
+    }
+}
+
+// PaintLine colors one line and ends it with a line break, or with the
+// ending it was parsed with by ParseLineEnding.
+func (p *Painter) PaintLine(line Line) error {
+    line, ending, ok := splitEnding(line)
+    defer func() { p.lineIndex++ }()
+    defer p.endLine(ending, ok)
+
+    cfg := p.cfg
+    if !cfg.Color {
+        for _, seg := range line {
+            if seg.isEscape() {
+                p.escape(seg.escape)
+                continue
+            }
+            p.out.RenderRun(nil, seg.text)
+        }
+        return nil
+    }
+
+    p.override = nil
+    if cfg.LineGradient != nil {
+        p.override = cfg.LineGradient(line)
+    }
+    p.fixed = false
+    if cfg.LineProgress != nil {
+        if progress, ok := cfg.LineProgress(line); ok {
+            p.fixed, p.fixedAt = true, p.shape(progress)
+        }
+    }
+
+    if cfg.Direction == "horizontal" && cfg.PerLine {
+        p.cell = 0
+        p.total = cfg.Cells(line)
+    }
+
+    if cfg.Direction == "vertical" && visibleLen(line) == 0 && (p.total > 1 || cfg.Period > 0) {
+        progress := p.progress(float64(p.lineIndex))
+        if p.fixed {
+            progress = p.fixedAt
+        }
+        color, err := p.colorAt(progress)
+        if err != nil {
+            return err
+        }
+
+        for _, seg := range line {
+            p.existing.apply(seg.escape)
+            p.escape(seg.escape)
+        }
+        color = cfg.finalColor(color)
+        p.out.RenderRun(&color, "")
+        return nil
+    }
+
+    spans, widths := cfg.lineSpans(line)
+    span, spanCell := -1, 0
+    kinds := cfg.jsonKinds(line)
+    markdown, heading := p.markdownKinds(line)
+    // A heading spans the whole gradient on its own
+    headingCell, headingWidth := 0, 0
+    for i, kind := range markdown {
+        if heading && kind == markdownText {
+            headingWidth += line[i].width
+        }
+    }
+    indent := cfg.IgnoreIndent
+    column, width := 0, visibleWidth(line)
+    for i, seg := range line {
+        // Existing escapes are passed through untouched; the next
+        // printable cluster re-emits the gradient color after them.
+        if seg.isEscape() {
+            p.existing.apply(seg.escape)
+            p.escape(seg.escape)
+            continue
+        }
+        at := column
+        column += seg.width
+        if kinds != nil && kinds[i] != jsonKey {
+            c, ok := jsonColors[kinds[i]]
+            if !ok {
+                p.out.RenderRun(nil, seg.text)
+                continue
+            }
+            c = cfg.finalColor(cfg.adjust(c))
+            p.out.RenderRun(&c, seg.text)
+            continue
+        }
+        if markdown != nil && markdown[i] != markdownText {
+            p.cell += seg.width
+            if markdown[i] == markdownMarker {
+                p.out.RenderRun(nil, seg.text)
+                continue
+            }
+            c := cfg.finalColor(markdownCodeColor)
+            p.out.RenderRun(&c, seg.text)
+            continue
+        }
+        if spans != nil {
+            if spans[i] < 0 {
+                if cfg.FieldSeparator == "" && cfg.MatchProgress == "global" {
+                    p.cell += seg.width
+                }
+                p.out.RenderRun(nil, seg.text)
+                continue
+            }
+            if spans[i] != span {
+                span, spanCell = spans[i], 0
+            }
+        }
+        // The gradient starts where the text does, past any indentation
+        if indent && isWhitespace(seg.text) {
+            p.out.RenderRun(nil, seg.text)
+            continue
+        }
+        indent = false
+        if cfg.SkipWhitespace != "" && isWhitespace(seg.text) {
+            if cfg.SkipWhitespace == "color" {
+                p.cell += seg.width
+                spanCell += seg.width
+            }
+            p.out.RenderRun(nil, seg.text)
+            continue
+        }
+
+        var progress float64
+        switch {
+        case p.fixed:
+            progress = p.fixedAt
+        case heading && cfg.Direction == "horizontal":
+            progress = p.progressOver(float64(headingCell), headingWidth)
+            headingCell += seg.width
+            p.cell += seg.width
+        case cfg.FieldSeparator != "" && cfg.FieldProgress == "column":
+            columns := len(widths)
+            if cfg.Rainbow {
+                // The rainbow ends where it starts, so the last column
+                // would take the first one's color
+                columns++
+            }
+            progress = p.progressOver(float64(span), columns)
+        case cfg.Direction == "horizontal" && spans != nil && (cfg.FieldSeparator != "" || cfg.MatchProgress == "match"):
+            // Every match or field gets the whole gradient
+            progress = p.progressOver(float64(spanCell), widths[span])
+            spanCell += seg.width
+        case cfg.Direction == "horizontal":
+            // Progress advances by display cells, not clusters
+            progress = p.progress(float64(p.cell) + float64(p.lineIndex)*cfg.LineShift)
+            p.cell += seg.width
+        default:
+            progress = p.progress(float64(p.lineIndex))
+        }
+
+        color, err := p.colorAt(progress)
+        if err != nil {
+            return err
+        }
+        color = cfg.jitter(color)
+        if cfg.BlendExisting > 0 && p.existing.set {
+            color = color.BlendLab(p.existing.color, cfg.BlendExisting)
+        }
+        if cfg.Fade != "" {
+            color = p.fade(color, at, width)
+        }
+
+        text := seg.text
+        if cfg.RuneHook != nil {
+            text, color = p.hook(text, color)
+        }
+        color = cfg.finalColor(color)
+        p.out.RenderRun(&color, text)
+    }
+    return nil
+}
+
+// endLine finishes a line. Renderers that can't write the input's own
+// line endings get a line break, except after a last line that had none.
+func (p *Painter) endLine(ending string, ok bool) {
+    if !ok {
+        p.out.LineBreak()
+        return
+    }
+    if e, isEnder := p.out.(lineEnder); isEnder {
+        e.EndLine(ending)
+        return
+    }
+    if ending != "" {
+        p.out.LineBreak()
+    }
+}
+
+// hook hands a cluster to RuneHook by its first rune. The whole cluster
+// is replaced only when the hook returns a different rune.
+func (p *Painter) hook(text string, c colorful.Color) (string, colorful.Color) {
+    defer func() { p.index++ }()
+    r, _ := utf8.DecodeRuneInString(text)
+    hooked, c := p.cfg.RuneHook(p.index, r, c)
+    if hooked != r {
+        text = string(hooked)
+    }
+    return text, c
+}
+
+// PaintLines colors one block, spreading the gradient across all of its
+// lines.
+func PaintLines(cfg *Config, out Renderer, lines []Line) error {
+    p := NewPainter(cfg, out, lines)
+    for _, line := range lines {
+        if err := p.PaintLine(line); err != nil {
+            return err
+        }
+    }
+    return nil
+}
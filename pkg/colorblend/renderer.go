@@ -0,0 +1,238 @@
+package colorblend
+
+import (
+    "fmt"
+    "io"
+    "strconv"
+    "strings"
+
+    "github.com/lucasb-eyer/go-colorful"
+)
+
+// Renderer writes gradient colored text in one output format. The gradient
+// engine calls RenderRun for every stretch of text and LineBreak at the end
+// of every line, then Flush once all input has been rendered.
+type Renderer interface {
+    // RenderRun writes text in color c, or uncolored when c is nil. text
+    // may be empty when only the color should be set.
+    RenderRun(c *colorful.Color, text string)
+    // LineBreak ends the current line.
+    LineBreak()
+    // Flush writes anything still buffered and ends the output.
+    Flush() error
+}
+
+// escapeRenderer is implemented by renderers that can pass the input's own
+// terminal escapes through. Escapes are dropped for other renderers.
+type escapeRenderer interface {
+    RenderEscape(seq string)
+}
+
+// lineEnder is implemented by renderers that can end a line with the
+// input's own line ending rather than a plain line break.
+type lineEnder interface {
+    EndLine(ending string)
+}
+
+// renderers holds the constructor of every format by name.
+var renderers = map[string]func(w io.Writer, cfg *Config) Renderer{
+    "ansi":        newANSIRenderer,
+    "html":        newHTMLRenderer,
+    "html-page":   newHTMLPageRenderer,
+    "svg":         newSVGRenderer,
+    "png":         newPNGRenderer,
+    "irc":         newIRCRenderer,
+    "bbcode":      newBBCodeRenderer,
+    "pango":       newPangoRenderer,
+    "tmux":        newTmuxRenderer,
+    "zsh":         newZshRenderer,
+    "bash-prompt": newBashPromptRenderer,
+    "fish":        newFishRenderer,
+    "rtf":         newRTFRenderer,
+    "latex":       newLatexRenderer,
+}
+
+// formats lists the format names in the order they are documented.
+var formats = []string{"ansi", "html", "html-page", "svg", "png", "irc", "bbcode", "pango", "tmux", "zsh", "bash-prompt", "fish", "rtf", "latex"}
+
+// RegisterRenderer adds an output format, replacing any existing format
+// with the same name. newRenderer is given the writer to render to and
+// the configuration in use.
+func RegisterRenderer(name string, newRenderer func(w io.Writer, cfg *Config) Renderer) {
+    if _, ok := renderers[name]; !ok {
+        formats = append(formats, name)
+    }
+    renderers[name] = newRenderer
+}
+
+// Formats returns the names of every output format.
+func Formats() []string {
+    return append([]string(nil), formats...)
+}
+
+// NewRenderer returns a Renderer for the named format writing to w.
+func NewRenderer(format string, w io.Writer, cfg *Config) (Renderer, error) {
+    newRenderer, ok := renderers[format]
+    if !ok {
+        return nil, fmt.Errorf("unknown format: %s", format)
+    }
+    return newRenderer(w, cfg), nil
+}
+
+// foregroundSGR formats a color as the parameters of a truecolor
+// foreground SGR sequence, without the leading CSI.
+func foregroundSGR(c colorful.Color) string {
+    r, g, b := c.Clamped().RGB255()
+    // Called for every colored cluster, so this avoids fmt
+    sgr := make([]byte, 0, len("38;2;255;255;255m"))
+    sgr = append(sgr, "38;2;"...)
+    sgr = strconv.AppendUint(sgr, uint64(r), 10)
+    sgr = append(sgr, ';')
+    sgr = strconv.AppendUint(sgr, uint64(g), 10)
+    sgr = append(sgr, ';')
+    sgr = strconv.AppendUint(sgr, uint64(b), 10)
+    sgr = append(sgr, 'm')
+    return string(sgr)
+}
+
+// ansiRenderer writes terminal SGR escapes at the configured Depth, and is
+// the only renderer that passes the input's escapes and line endings
+// through.
+type ansiRenderer struct {
+    w         io.Writer
+    cfg       *Config
+    colored   bool // a color is set that hasn't been reset
+    painted   bool // the terminal shows our color rather than the input's
+    ditherPos int
+    ditherErr [3]float64
+    nearest   map[colorful.Color]int // palette lookups when not dithering
+    sgr       string                 // the color in effect on this line
+    held      string                 // the last line ending, held back for the final reset
+}
+
+func newANSIRenderer(w io.Writer, cfg *Config) Renderer {
+    return &ansiRenderer{w: w, cfg: cfg}
+}
+
+// write writes s after any line ending held back for FinalReset "end".
+func (a *ansiRenderer) write(s string) {
+    if a.held != "" {
+        io.WriteString(a.w, a.held)
+        a.held = ""
+    }
+    io.WriteString(a.w, s)
+}
+
+func (a *ansiRenderer) RenderRun(c *colorful.Color, text string) {
+    if c == nil {
+        // Uncolored text after a color needs the default foreground
+        // back, though whitespace looks the same either way unless it
+        // is underlined or reversed
+        if a.painted && (strings.Trim(text, " \t") != "" || a.cfg.underlined() || a.cfg.Reverse) {
+            a.write("\x1b[" + a.cfg.attributesOff() + a.colorOff())
+            a.painted = false
+            a.sgr = ""
+        }
+        a.write(text)
+        return
+    }
+    a.colored = true
+    a.painted = true
+    // Stepped or quantized gradients give runs of the same color, which
+    // only need setting once
+    sgr := a.cfg.attributesOn() + a.color(*c)
+    if sgr == a.sgr {
+        a.write(text)
+        return
+    }
+    a.sgr = sgr
+    a.write("\x1b[" + sgr + text)
+}
+
+// attributesOn returns the SGR parameters of the text attributes, each
+// followed by a semicolon so a color can come after them.
+func (cfg *Config) attributesOn() string {
+    var b strings.Builder
+    for _, attr := range []struct {
+        on    bool
+        param string
+    }{{cfg.Bold, "1;"}, {cfg.Dim, "2;"}, {cfg.Italic, "3;"}, {cfg.underlined(), "4;"}, {cfg.Reverse, "7;"}} {
+        if attr.on {
+            b.WriteString(attr.param)
+        }
+    }
+    return b.String()
+}
+
+// underlined reports whether colored text is underlined, as it is when
+// the gradient colors the underline.
+func (cfg *Config) underlined() bool {
+    return cfg.Underline || cfg.Target == "underline"
+}
+
+// attributesOff returns the SGR parameters turning the text attributes
+// off again, leaving any others alone.
+func (cfg *Config) attributesOff() string {
+    var b strings.Builder
+    if cfg.Bold || cfg.Dim {
+        b.WriteString("22;")
+    }
+    if cfg.Italic {
+        b.WriteString("23;")
+    }
+    if cfg.underlined() {
+        b.WriteString("24;")
+    }
+    if cfg.Reverse {
+        b.WriteString("27;")
+    }
+    return b.String()
+}
+
+func (a *ansiRenderer) RenderEscape(seq string) {
+    a.write(seq)
+    // The input's own escapes may have changed the color
+    a.sgr = ""
+    a.painted = false
+}
+
+func (a *ansiRenderer) LineBreak() {
+    a.EndLine("\n")
+}
+
+func (a *ansiRenderer) EndLine(ending string) {
+    switch a.cfg.FinalReset {
+    case "line":
+        if a.colored {
+            a.write("\x1b[0m")
+            a.colored = false
+            a.painted = false
+        }
+        a.write(ending)
+    case "end":
+        // Until more follows this may be the last line, whose reset
+        // belongs before its ending
+        a.write("")
+        a.held = ending
+    default:
+        a.write(ending)
+    }
+    // Every line sets its own colors, so it can be cut out on its own
+    a.sgr = ""
+    // Dithering works along each line
+    a.ditherPos = 0
+    a.ditherErr = [3]float64{}
+}
+
+// Flush resets the terminal colors once anything has been colored, ahead
+// of the last line ending.
+func (a *ansiRenderer) Flush() error {
+    if a.colored && a.cfg.FinalReset != "none" {
+        io.WriteString(a.w, "\x1b[0m")
+        a.colored = false
+        a.painted = false
+    }
+    _, err := io.WriteString(a.w, a.held)
+    a.held = ""
+    return err
+}
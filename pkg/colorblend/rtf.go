@@ -0,0 +1,68 @@
+package colorblend
+
+import (
+    "fmt"
+    "io"
+    "strings"
+
+    "github.com/lucasb-eyer/go-colorful"
+)
+
+// rtfFont is the font of --format rtf documents, which word processors
+// can be relied on to have.
+const rtfFont = "Courier New"
+
+// writeRTF writes lines as an RTF document, with every color
+// used collected into the color table up front.
+func writeRTF(w io.Writer, _ *Config, lines [][]run) error {
+    index := map[string]int{}
+    var table strings.Builder
+    for _, runs := range lines {
+        for _, r := range runs {
+            if _, ok := index[r.hex]; ok || r.hex == "" {
+                continue
+            }
+            // Entry 0 is the automatic color, left empty
+            index[r.hex] = len(index) + 1
+            c, _ := colorful.Hex(r.hex)
+            red, green, blue := c.RGB255()
+            fmt.Fprintf(&table, `\red%d\green%d\blue%d;`, red, green, blue)
+        }
+    }
+
+    fmt.Fprintf(w, `{\rtf1\ansi\deff0{\fonttbl{\f0\fmodern %s;}}{\colortbl;%s}`+"\n", rtfFont, table.String())
+    fmt.Fprint(w, `\f0\fs20`)
+    for i, runs := range lines {
+        if i > 0 {
+            fmt.Fprint(w, `\line`)
+        }
+        fmt.Fprintln(w)
+        for _, r := range runs {
+            fmt.Fprintf(w, `\cf%d %s`, index[r.hex], rtfEscape(r.text))
+        }
+    }
+    _, err := fmt.Fprintln(w, `\cf0}`)
+    return err
+}
+
+// rtfEscape escapes RTF control characters and writes non-ASCII
+// characters as \u escapes, with ? for readers that don't know them.
+func rtfEscape(s string) string {
+    var b strings.Builder
+    for _, r := range s {
+        switch {
+        case r == '\\' || r == '{' || r == '}':
+            b.WriteByte('\\')
+            b.WriteRune(r)
+        case r < 0x80:
+            b.WriteRune(r)
+        case r > 0xFFFF:
+            // \u takes signed 16 bit values, so split into surrogates
+            r -= 0x10000
+            fmt.Fprintf(&b, `\u%d?\u%d?`, int16(0xD800+(r>>10)), int16(0xDC00+(r&0x3FF)))
+        default:
+            fmt.Fprintf(&b, `\u%d?`, int16(r))
+        }
+    }
+    return b.String()
+}
@@ -0,0 +1,42 @@
+package colorblend
+
+import (
+    "github.com/lucasb-eyer/go-colorful"
+)
+
+// Simulations lists the color vision deficiencies Simulate can show.
+var Simulations = []string{"protanopia", "deuteranopia", "tritanopia"}
+
+// cvdMatrices are the full severity dichromacy matrices of Machado,
+// Oliveira and Fernandes (2009), applied to linear RGB.
+var cvdMatrices = map[string][3][3]float64{
+    "protanopia": {
+        {0.152286, 1.052583, -0.204868},
+        {0.114503, 0.786281, 0.099216},
+        {-0.003882, -0.048116, 1.051998},
+    },
+    "deuteranopia": {
+        {0.367322, 0.860646, -0.227968},
+        {0.280085, 0.672501, 0.047413},
+        {-0.011820, 0.042940, 0.968881},
+    },
+    "tritanopia": {
+        {1.255528, -0.076749, -0.178779},
+        {-0.078411, 0.930809, 0.147602},
+        {0.004733, 0.691367, 0.303900},
+    },
+}
+
+// simulate returns c as it looks with the Simulate deficiency.
+func (cfg *Config) simulate(c colorful.Color) colorful.Color {
+    m, ok := cvdMatrices[cfg.Simulate]
+    if !ok {
+        return c
+    }
+    r, g, b := c.LinearRgb()
+    return colorful.LinearRgb(
+        m[0][0]*r+m[0][1]*g+m[0][2]*b,
+        m[1][0]*r+m[1][1]*g+m[1][2]*b,
+        m[2][0]*r+m[2][1]*g+m[2][2]*b,
+    ).Clamped()
+}
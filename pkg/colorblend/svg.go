@@ -0,0 +1,57 @@
+package colorblend
+
+import (
+    "fmt"
+    "html"
+    "io"
+    "math"
+
+    "github.com/rivo/uniseg"
+)
+
+// svgFontSize and svgLineHeight set the text metrics of --format svg, in
+// pixels. Monospace glyphs are assumed to be 0.6em wide.
+const (
+    svgFontSize   = 14
+    svgLineHeight = 1.25 * svgFontSize
+    svgCharWidth  = 0.6 * svgFontSize
+    svgPadding    = svgFontSize
+)
+
+// writeSVGLine writes a line of runs as tspan elements.
+func writeSVGLine(w io.Writer, cfg *Config, y float64, runs []run) {
+    fmt.Fprintf(w, `  <text x="%d" y="%g">`, svgPadding, y)
+    for _, r := range runs {
+        fill := r.hex
+        if fill == "" {
+            fill = cfg.textColor()
+        }
+        fmt.Fprintf(w, `<tspan fill="%s">%s</tspan>`, fill, html.EscapeString(r.text))
+    }
+    fmt.Fprintln(w, "</text>")
+}
+
+// writeSVG writes lines as a complete SVG document.
+func writeSVG(w io.Writer, cfg *Config, lines [][]run) error {
+    cols := 0
+    for _, runs := range lines {
+        width := 0
+        for _, r := range runs {
+            width += uniseg.StringWidth(r.text)
+        }
+        cols = max(cols, width)
+    }
+    width := int(math.Ceil(float64(cols)*svgCharWidth)) + 2*svgPadding
+    height := int(math.Ceil(float64(len(lines))*svgLineHeight)) + 2*svgPadding
+
+    fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n", width, height, width, height)
+    fmt.Fprintf(w, `  <rect width="100%%" height="100%%" fill="%s"/>`+"\n", cfg.pageBackground())
+    fmt.Fprintf(w, `  <g font-family="%s" font-size="%d" xml:space="preserve">`+"\n", html.EscapeString(cfg.Font), svgFontSize)
+    for i, runs := range lines {
+        // Baselines sit a font size below the top of each line
+        writeSVGLine(w, cfg, svgPadding+float64(i)*svgLineHeight+svgFontSize, runs)
+    }
+    fmt.Fprintln(w, "  </g>")
+    _, err := fmt.Fprintln(w, "</svg>")
+    return err
+}
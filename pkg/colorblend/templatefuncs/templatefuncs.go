@@ -0,0 +1,46 @@
+// Package templatefuncs provides text/template functions that color text
+// with colorblend gradients:
+//
+//    {{ gradient "#f00" "#00f" .Title }}
+//    {{ rainbow .Banner }}
+package templatefuncs
+
+import (
+    "fmt"
+    "text/template"
+
+    "github.com/ocodo/colorblend/pkg/colorblend"
+)
+
+// FuncMap returns the gradient and rainbow functions, ready for
+// template.Funcs.
+func FuncMap() template.FuncMap {
+    return template.FuncMap{
+        "gradient": gradient,
+        "rainbow":  rainbow,
+    }
+}
+
+// gradient colors its last argument through the hex color stops before
+// it.
+func gradient(args ...string) (string, error) {
+    if len(args) < 2 {
+        return "", fmt.Errorf("gradient needs at least one color and the text")
+    }
+    z, err := colorblend.New(colorblend.WithStops(args[:len(args)-1]...))
+    if err != nil {
+        return "", err
+    }
+    return z.ColorizeString(args[len(args)-1]), nil
+}
+
+// rainbow colors text through the full hue circle.
+func rainbow(text string) (string, error) {
+    cfg := colorblend.DefaultConfig()
+    cfg.Rainbow = true
+    z, err := colorblend.New(colorblend.WithConfig(cfg))
+    if err != nil {
+        return "", err
+    }
+    return z.ColorizeString(text), nil
+}
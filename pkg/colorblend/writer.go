@@ -0,0 +1,99 @@
+package colorblend
+
+import (
+    "bufio"
+    "bytes"
+    "io"
+    "strings"
+    "sync"
+)
+
+// defaultWriterPeriod is the cycle length of vertical gradients in a
+// Writer, in lines, when no Period is configured.
+const defaultWriterPeriod = 16
+
+// Writer colorizes text written through it as ANSI escapes. Lines are
+// painted as soon as their newline arrives, keeping \r\n endings, so
+// partial lines and UTF-8 sequences split across writes are held back
+// until they are complete.
+//
+// A Writer never sees the whole text, so horizontal gradients restart on
+// every line unless a Period is configured, vertical gradients cycle
+// every 16 lines by default and every colored line ends with a reset.
+type Writer struct {
+    mu      sync.Mutex
+    cfg     *Config
+    out     *bufio.Writer
+    r       Renderer
+    painter *Painter
+    pending []byte
+}
+
+// NewWriter returns a Writer that colors everything written to it and
+// passes the result on to w.
+func NewWriter(w io.Writer, opts ...Option) *Writer {
+    cfg := newConfig(opts)
+    if cfg.Period == 0 {
+        if cfg.Direction == "vertical" {
+            cfg.Period = defaultWriterPeriod
+        } else {
+            cfg.PerLine = true
+        }
+    }
+    // Lines written so far would otherwise wait for the next Write to
+    // get their line ending
+    if cfg.FinalReset == "end" {
+        cfg.FinalReset = "line"
+    }
+    // Buffering hands w whole lines rather than every escape and cluster
+    // on its own
+    out := bufio.NewWriter(w)
+    r := newANSIRenderer(out, cfg)
+    return &Writer{cfg: cfg, out: out, r: r, painter: NewPainter(cfg, r, nil)}
+}
+
+// Write paints every complete line in p. The rest is kept until a later
+// Write completes it or Flush is called.
+func (w *Writer) Write(p []byte) (int, error) {
+    w.mu.Lock()
+    defer w.mu.Unlock()
+
+    w.pending = append(w.pending, p...)
+    for {
+        i := bytes.IndexByte(w.pending, '\n')
+        if i < 0 {
+            break
+        }
+        line, ending := string(w.pending[:i]), "\n"
+        w.pending = w.pending[i+1:]
+        if strings.HasSuffix(line, "\r") {
+            line, ending = line[:len(line)-1], "\r\n"
+        }
+        if err := w.painter.PaintLine(w.cfg.ParseLineEnding(line, ending)); err != nil {
+            return len(p), err
+        }
+    }
+    // Keep the unfinished line in a fresh buffer so the slice doesn't
+    // grow without bound
+    w.pending = append([]byte(nil), w.pending...)
+    return len(p), w.out.Flush()
+}
+
+// Flush paints any unfinished line, without ending it, and resets the
+// terminal colors.
+func (w *Writer) Flush() error {
+    w.mu.Lock()
+    defer w.mu.Unlock()
+
+    if len(w.pending) > 0 {
+        line := string(w.pending)
+        w.pending = nil
+        if err := w.painter.PaintLine(w.cfg.ParseLineEnding(line, "")); err != nil {
+            return err
+        }
+    }
+    if err := w.r.Flush(); err != nil {
+        return err
+    }
+    return w.out.Flush()
+}
@@ -0,0 +1,56 @@
+package colorblend
+
+import (
+    "math"
+
+    "github.com/lucasb-eyer/go-colorful"
+)
+
+// xterm16 holds the default xterm values for the 16 basic ANSI colors.
+var xterm16 = [16][3]uint8{
+    {0x00, 0x00, 0x00}, {0xcd, 0x00, 0x00}, {0x00, 0xcd, 0x00}, {0xcd, 0xcd, 0x00},
+    {0x00, 0x00, 0xee}, {0xcd, 0x00, 0xcd}, {0x00, 0xcd, 0xcd}, {0xe5, 0xe5, 0xe5},
+    {0x7f, 0x7f, 0x7f}, {0xff, 0x00, 0x00}, {0x00, 0xff, 0x00}, {0xff, 0xff, 0x00},
+    {0x5c, 0x5c, 0xff}, {0xff, 0x00, 0xff}, {0x00, 0xff, 0xff}, {0xff, 0xff, 0xff},
+}
+
+// cubeLevels are the channel intensities of the 6x6x6 color cube.
+var cubeLevels = [6]uint8{0, 95, 135, 175, 215, 255}
+
+// xterm256 returns the RGB value of an entry in the xterm 256-color palette.
+func xterm256(n int) colorful.Color {
+    var r, g, b uint8
+    switch {
+    case n < 16:
+        r, g, b = xterm16[n][0], xterm16[n][1], xterm16[n][2]
+    case n < 232:
+        n -= 16
+        r, g, b = cubeLevels[n/36], cubeLevels[(n/6)%6], cubeLevels[n%6]
+    default:
+        v := uint8(8 + 10*(n-232))
+        r, g, b = v, v, v
+    }
+    return colorful.Color{R: float64(r) / 255, G: float64(g) / 255, B: float64(b) / 255}
+}
+
+// xtermPalette caches every xterm256 entry for nearestXterm.
+var xtermPalette = func() (palette [256]colorful.Color) {
+    for n := range palette {
+        palette[n] = xterm256(n)
+    }
+    return palette
+}()
+
+// nearestXterm returns the index in [from, to) of the xterm palette entry
+// closest to c.
+func nearestXterm(c colorful.Color, from, to int) int {
+    best, bestDist := from, math.Inf(1)
+    for n := from; n < to; n++ {
+        p := xtermPalette[n]
+        d := (c.R-p.R)*(c.R-p.R) + (c.G-p.G)*(c.G-p.G) + (c.B-p.B)*(c.B-p.B)
+        if d < bestDist {
+            best, bestDist = n, d
+        }
+    }
+    return best
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+    "fmt"
+    "sort"
+    "strings"
+)
+
+// presets are the built-in named gradients, each a list of evenly spaced
+// color stops.
+var presets = map[string][]string{
+    "sunset":    {"#355C7D", "#6C5B7B", "#C06C84", "#F67280", "#F8B195"},
+    "ocean":     {"#03045E", "#0077B6", "#00B4D8", "#90E0EF", "#CAF0F8"},
+    "fire":      {"#370617", "#9D0208", "#DC2F02", "#F48C06", "#FFBA08"},
+    "forest":    {"#081C15", "#1B4332", "#2D6A4F", "#40916C", "#74C69D", "#B7E4C7"},
+    "aurora":    {"#00FFA3", "#03E1FF", "#DC1FFF"},
+    "vaporwave": {"#FF71CE", "#01CDFE", "#05FFA1", "#B967FF", "#FFFB96"},
+    "pride":     {"#E40303", "#FF8C00", "#FFED00", "#008026", "#004DFF", "#750787"},
+    "trans":     {"#5BCEFA", "#F5A9B8", "#FFFFFF", "#F5A9B8", "#5BCEFA"},
+    "nonbinary": {"#FCF434", "#FFFFFF", "#9C59D1", "#2C2C2C"},
+    "bisexual":  {"#D60270", "#9B4F96", "#0038A8"},
+    "lesbian":   {"#D52D00", "#FF9A56", "#FFFFFF", "#D362A4", "#A30262"},
+    "pansexual": {"#FF218C", "#FFD800", "#21B1FF"},
+    "asexual":   {"#000000", "#A3A3A3", "#FFFFFF", "#800080"},
+}
+
+// presetNames returns the preset names in alphabetical order.
+func presetNames() []string {
+    names := make([]string, 0, len(presets))
+    for name := range presets {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    return names
+}
+
+// listPresets prints every preset with its stops.
+func listPresets() {
+    for _, name := range presetNames() {
+        fmt.Printf("%-10s %s\n", name, strings.Join(presets[name], " "))
+    }
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+    "bufio"
+    "math"
+    "os"
+    "slices"
+    "strings"
+
+    "github.com/ocodo/colorblend/pkg/colorblend"
+    "github.com/spf13/cobra"
+    "golang.org/x/term"
+)
+
+var (
+    previewWidth  int
+    previewLabels bool
+)
+
+var previewCmd = &cobra.Command{
+    Use:   "preview [flags]",
+    Short: "Show the configured gradient as a bar, without any input text",
+    Args:  cobra.NoArgs,
+    Run: func(cmd *cobra.Command, args []string) {
+        configure(cmd, args)
+        if previewWidth < 0 {
+            usageError(cmd, "--width cannot be negative.")
+        }
+        width := terminalWidth(previewWidth)
+
+        cfg.Color = colorEnabled(colorMode)
+        if format != "ansi" {
+            cfg.Color = colorMode != "never"
+        }
+        if detectBackground && cfg.Color && format == "ansi" {
+            adaptToTerminal(cmd)
+        }
+        stdout = bufio.NewWriter(os.Stdout)
+        out, err := colorblend.NewRenderer(format, stdout, &cfg)
+        if err != nil {
+            runtimeError(err)
+        }
+
+        lines := []colorblend.Line{cfg.ParseLine(strings.Repeat("█", width))}
+        if err := colorblend.PaintLines(&cfg, out, lines); err != nil {
+            runtimeError(err)
+        }
+        if previewLabels {
+            // Labels are plain text, so paint them without a gradient
+            labels := cfg
+            labels.Color = false
+            line := cfg.ParseLine(stopLabels(cfg.Stops, width))
+            if err := colorblend.PaintLines(&labels, out, []colorblend.Line{line}); err != nil {
+                runtimeError(err)
+            }
+        }
+        if err := finish(out); err != nil {
+            runtimeError(err)
+        }
+    },
+}
+
+// terminalWidth returns width, or when it is 0 the width of the terminal
+// on stdout, 80 cells if there is none.
+func terminalWidth(width int) int {
+    if width > 0 {
+        return width
+    }
+    if w, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && w > 0 {
+        return w
+    }
+    return 80
+}
+
+// stopLabels lays the stop colors out under a bar of width cells, each
+// centered where its stop sits along the bar. Labels that would overlap
+// the previous one are left out.
+func stopLabels(stops []string, width int) string {
+    if cfg.Rainbow || cfg.Colormap != "" || cfg.Cubehelix != nil {
+        return ""
+    }
+    _, positions, err := colorblend.ParseStopPositions(stops)
+    if err != nil {
+        return ""
+    }
+    labels := make([]string, len(stops))
+    places := make([]float64, len(stops))
+    for i, stop := range stops {
+        labels[i], _, _ = strings.Cut(stop, "@")
+        switch {
+        case positions != nil:
+            places[i] = positions[i]
+        case len(stops) > 1:
+            places[i] = float64(i) / float64(len(stops)-1)
+        }
+        if cfg.Invert {
+            places[i] = 1 - places[i]
+        }
+    }
+    if cfg.Invert {
+        slices.Reverse(labels)
+        slices.Reverse(places)
+    }
+    row := []rune(strings.Repeat(" ", width))
+    next := 0
+    for i, label := range labels {
+        pos := int(math.Round(places[i] * float64(width-1)))
+        pos = max(0, min(pos-len(label)/2, width-len(label)))
+        if pos < next {
+            continue
+        }
+        copy(row[pos:], []rune(label))
+        next = pos + len(label) + 1
+    }
+    return strings.TrimRight(string(row), " ")
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+    "math"
+    "math/rand"
+    "strings"
+
+    "github.com/lucasb-eyer/go-colorful"
+)
+
+// randomKinds are the supported --random generators.
+var randomKinds = []string{"happy", "warm"}
+
+// randomColor picks a valid color and returns it with its hue, using the
+// same restricted HCL ranges as go-colorful's HappyColor and WarmColor,
+// but drawing from r so --seed makes it reproducible. Like those, it
+// draws the hue again with every try, as some hues have no valid colors
+// within the ranges.
+func randomColor(kind string, r *rand.Rand) (colorful.Color, float64) {
+    for {
+        h := r.Float64() * 360
+        var c colorful.Color
+        if kind == "warm" {
+            c = colorful.Hcl(h, 0.1+r.Float64()*0.3, 0.2+r.Float64()*0.3)
+        } else {
+            c = colorful.Hcl(h, 0.5+r.Float64()*0.3, 0.5+r.Float64()*0.3)
+        }
+        if c.IsValid() {
+            return c, h
+        }
+    }
+}
+
+// randomStops returns a random start and end color whose hues are at
+// least 60 degrees apart, so the gradient is always visible.
+func randomStops(kind string, r *rand.Rand) []string {
+    start, h1 := randomColor(kind, r)
+    for {
+        end, h2 := randomColor(kind, r)
+        if d := math.Abs(h1 - h2); math.Min(d, 360-d) >= 60 {
+            return []string{strings.ToUpper(start.Hex()), strings.ToUpper(end.Hex())}
+        }
+    }
+}
+
+// seededRand returns a generator seeded with --seed, or with a random
+// seed when it is 0.
+func seededRand() *rand.Rand {
+    s := seed
+    if s == 0 {
+        s = rand.Int63()
+    }
+    return rand.New(rand.NewSource(s))
+}
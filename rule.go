@@ -0,0 +1,65 @@
+package main
+
+import (
+    "bufio"
+    "os"
+    "strings"
+
+    "github.com/ocodo/colorblend/pkg/colorblend"
+    "github.com/rivo/uniseg"
+    "github.com/spf13/cobra"
+)
+
+var (
+    ruleChar  string
+    ruleWidth int
+)
+
+var ruleCmd = &cobra.Command{
+    Use:   "rule [flags]",
+    Short: "Print a gradient divider line across the terminal",
+    Args:  cobra.NoArgs,
+    Run: func(cmd *cobra.Command, args []string) {
+        configure(cmd, args)
+        if ruleWidth < 0 {
+            usageError(cmd, "--width cannot be negative.")
+        }
+        if uniseg.StringWidth(ruleChar) == 0 {
+            usageError(cmd, "--char must be at least one visible character.")
+        }
+
+        cfg.Color = colorEnabled(colorMode)
+        if format != "ansi" {
+            cfg.Color = colorMode != "never"
+        }
+        stdout = bufio.NewWriter(os.Stdout)
+        out, err := colorblend.NewRenderer(format, stdout, &cfg)
+        if err != nil {
+            runtimeError(err)
+        }
+
+        line := cfg.ParseLine(ruleText(ruleChar, terminalWidth(ruleWidth)))
+        if err := colorblend.PaintLines(&cfg, out, []colorblend.Line{line}); err != nil {
+            runtimeError(err)
+        }
+        if err := finish(out); err != nil {
+            runtimeError(err)
+        }
+    },
+}
+
+// ruleText repeats pattern to fill width cells, ending with as much of it
+// as still fits.
+func ruleText(pattern string, width int) string {
+    var b strings.Builder
+    for used := 0; ; {
+        graphemes := uniseg.NewGraphemes(pattern)
+        for graphemes.Next() {
+            if used+graphemes.Width() > width {
+                return b.String()
+            }
+            b.WriteString(graphemes.Str())
+            used += graphemes.Width()
+        }
+    }
+}
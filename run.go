@@ -0,0 +1,111 @@
+package main
+
+import (
+    "bufio"
+    "errors"
+    "fmt"
+    "os"
+    "os/exec"
+    "os/signal"
+    "syscall"
+
+    "github.com/ocodo/colorblend/pkg/colorblend"
+    "github.com/spf13/cobra"
+    "golang.org/x/term"
+)
+
+var runCmd = &cobra.Command{
+    Use:   "run [flags] [--] command [arg...]",
+    Short: "Run a command on a pseudo-terminal, coloring its output as it arrives, and exit with its status",
+    Args:  cobra.MinimumNArgs(1),
+    Run: func(cmd *cobra.Command, args []string) {
+        // The output is colored line by line, as with --stream
+        cmd.Flags().Set("stream", "true")
+        configure(cmd, args)
+        if output != "" {
+            f, err := os.Create(output)
+            if err != nil {
+                runtimeError(err)
+            }
+            defer f.Close()
+            os.Stdout = f
+        }
+        if format == "png" && term.IsTerminal(int(os.Stdout.Fd())) {
+            usageError(cmd, "Refusing to write PNG data to a terminal; use -o FILE or redirect stdout.")
+        }
+
+        cfg.Color = colorEnabled(colorMode)
+        if format != "ansi" {
+            cfg.Color = colorMode != "never"
+        }
+        if detectBackground && cfg.Color && format == "ansi" {
+            adaptToTerminal(cmd)
+        }
+        stdout = bufio.NewWriter(os.Stdout)
+        out, err := colorblend.NewRenderer(format, stdout, &cfg)
+        if err != nil {
+            runtimeError(err)
+        }
+        code, err := run(out, args)
+        if err != nil {
+            runtimeError(err)
+        }
+        os.Exit(code)
+    },
+}
+
+// run starts command with its output on a pseudo-terminal, so it writes
+// as it would to a terminal instead of buffering for a pipe, colors each
+// line as it comes and returns the command's exit status. A command
+// killed by a signal gets 128 plus the signal, as in the shell.
+func run(out colorblend.Renderer, command []string) (int, error) {
+    c := exec.Command(command[0], command[1:]...)
+    output, err := startPty(c)
+    if err != nil {
+        return 0, err
+    }
+    defer output.Close()
+
+    // The command runs in a session of its own, so Ctrl-C reaches
+    // colorblend only and is passed on
+    interrupt := make(chan os.Signal, 1)
+    signal.Notify(interrupt, os.Interrupt)
+    defer signal.Stop(interrupt)
+    go func() {
+        for sig := range interrupt {
+            c.Process.Signal(sig)
+        }
+    }()
+
+    p := colorblend.NewPainter(&cfg, out, nil)
+    if totalUnits > 0 {
+        p.SetTotal(totalUnits)
+    }
+    err = scanLines(output, func(line colorblend.Line) error {
+        if err := p.PaintLine(line); err != nil {
+            return err
+        }
+        return stdout.Flush()
+    })
+    if err != nil {
+        // Nothing would read the rest of the output, so don't leave the
+        // command running behind
+        c.Process.Kill()
+        c.Wait()
+        return 0, fmt.Errorf("reading %s: %w", command[0], err)
+    }
+    if err := finish(out); err != nil {
+        c.Process.Kill()
+        c.Wait()
+        return 0, err
+    }
+
+    var exit *exec.ExitError
+    if err := c.Wait(); !errors.As(err, &exit) {
+        return 0, err
+    }
+    if status, ok := exit.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+        return 128 + int(status.Signal()), nil
+    }
+    return exit.ExitCode(), nil
+}
@@ -0,0 +1,73 @@
+//go:build !windows
+
+package main
+
+import (
+    "errors"
+    "io"
+    "os"
+    "os/exec"
+    "os/signal"
+    "syscall"
+
+    "github.com/creack/pty"
+    "golang.org/x/term"
+)
+
+// startPty starts c with its output and errors on a new pseudo-terminal,
+// sized like the one colorblend writes to, and returns the reading end.
+// c keeps colorblend's standard input, so it can still be typed to.
+func startPty(c *exec.Cmd) (io.ReadCloser, error) {
+    ptmx, tty, err := pty.Open()
+    if err != nil {
+        return nil, err
+    }
+    defer tty.Close()
+    size, err := pty.GetsizeFull(os.Stdout)
+    if err != nil {
+        size = nil
+    } else if err := pty.Setsize(ptmx, size); err != nil {
+        ptmx.Close()
+        return nil, err
+    }
+    // Nothing is typed to the pty, and raw mode passes line endings
+    // through as the command wrote them instead of turning them into \r\n
+    if _, err := term.MakeRaw(int(tty.Fd())); err != nil {
+        ptmx.Close()
+        return nil, err
+    }
+
+    c.Stdin, c.Stdout, c.Stderr = os.Stdin, tty, tty
+    // The pty, which is the command's fd 1, becomes its controlling
+    // terminal rather than whatever stdin is
+    c.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true, Ctty: 1}
+    if err := c.Start(); err != nil {
+        ptmx.Close()
+        return nil, err
+    }
+
+    if size != nil {
+        resized := make(chan os.Signal, 1)
+        signal.Notify(resized, syscall.SIGWINCH)
+        go func() {
+            for range resized {
+                pty.InheritSize(os.Stdout, ptmx)
+            }
+        }()
+    }
+    return ptyReader{ptmx}, nil
+}
+
+// ptyReader ends the output at the EIO Linux reads from a pty once the
+// command and everything it started have closed their end.
+type ptyReader struct {
+    *os.File
+}
+
+func (r ptyReader) Read(p []byte) (int, error) {
+    n, err := r.File.Read(p)
+    if errors.Is(err, syscall.EIO) {
+        err = io.EOF
+    }
+    return n, err
+}
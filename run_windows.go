@@ -0,0 +1,15 @@
+//go:build windows
+
+package main
+
+import (
+    "errors"
+    "io"
+    "os/exec"
+)
+
+// startPty would start c on a pseudo-terminal, which Windows consoles
+// don't offer the way run needs.
+func startPty(c *exec.Cmd) (io.ReadCloser, error) {
+    return nil, errors.New("run is not supported on Windows")
+}
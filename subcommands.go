@@ -0,0 +1,122 @@
+package main
+
+import (
+    "bufio"
+    "encoding/json"
+    "fmt"
+    "os"
+    "path/filepath"
+    "slices"
+    "strings"
+
+    "github.com/lucasb-eyer/go-colorful"
+    "github.com/ocodo/colorblend/pkg/colorblend"
+    "github.com/spf13/cobra"
+)
+
+var applyCmd = &cobra.Command{
+    Use:   "apply [file...] [flags]",
+    Short: "Color text with a gradient, as colorblend does without a command",
+    Args:  cobra.ArbitraryArgs,
+    Run:   apply,
+}
+
+// convertTo is the palette format written by convert.
+var convertTo string
+
+// convertFormats are the palette formats convert can write.
+var convertFormats = []string{"hex", "json", "gpl"}
+
+var convertCmd = &cobra.Command{
+    Use:   "convert FILE [flags]",
+    Short: "Convert a palette file, Base16 scheme or image into a list of gradient stops",
+    Args:  cobra.ExactArgs(1),
+    Run: func(cmd *cobra.Command, args []string) {
+        if !slices.Contains(convertFormats, convertTo) {
+            usageError(cmd, "Invalid value for --to: %s. Must be one of: %s.", convertTo, strings.Join(convertFormats, ", "))
+        }
+
+        stops, err := loadStops(args[0])
+        if err != nil {
+            runtimeError(err)
+        }
+        if err := writePalette(args[0], stops); err != nil {
+            runtimeError(err)
+        }
+    },
+}
+
+// loadStops reads colors from anything colorblend takes stops from: a
+// palette file, a Base16 scheme (in base00.. order) or an image.
+func loadStops(path string) ([]string, error) {
+    switch strings.ToLower(filepath.Ext(path)) {
+    case ".yaml", ".yml":
+        scheme, err := loadBase16(path)
+        if err != nil {
+            return nil, err
+        }
+        var stops []string
+        for i := 0; i < 0x18; i++ {
+            if hex, ok := scheme[fmt.Sprintf("base%02X", i)]; ok {
+                stops = append(stops, hex)
+            }
+        }
+        return stops, nil
+    case ".png", ".jpg", ".jpeg", ".gif":
+        return paletteFromImage(path, imageColors)
+    }
+    return loadPaletteFile(path)
+}
+
+// writePalette prints stops in the --to format.
+func writePalette(path string, stops []string) error {
+    switch convertTo {
+    case "json":
+        data, err := json.MarshalIndent(stops, "", "  ")
+        if err != nil {
+            return err
+        }
+        fmt.Println(string(data))
+    case "gpl":
+        name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+        fmt.Printf("GIMP Palette\nName: %s\n#\n", name)
+        for _, hex := range stops {
+            c, err := colorful.Hex(hex)
+            if err != nil {
+                return err
+            }
+            r, g, b := c.RGB255()
+            fmt.Printf("%3d %3d %3d\t%s\n", r, g, b, hex)
+        }
+    default:
+        for _, hex := range stops {
+            fmt.Println(hex)
+        }
+    }
+    return nil
+}
+
+var demoCmd = &cobra.Command{
+    Use:   "demo [flags]",
+    Short: "Show every built-in preset as a gradient bar",
+    Args:  cobra.NoArgs,
+    Run: func(cmd *cobra.Command, args []string) {
+        configure(cmd, args)
+        cfg.Color = colorEnabled(colorMode)
+        stdout = bufio.NewWriter(os.Stdout)
+        out, err := colorblend.NewRenderer("ansi", stdout, &cfg)
+        if err != nil {
+            runtimeError(err)
+        }
+        for _, name := range presetNames() {
+            cfg.Stops = presets[name]
+            line := cfg.ParseLine(fmt.Sprintf("%-10s %s", name, strings.Repeat("█", 48)))
+            if err := colorblend.PaintLines(&cfg, out, []colorblend.Line{line}); err != nil {
+                runtimeError(err)
+            }
+        }
+        if err := finish(out); err != nil {
+            runtimeError(err)
+        }
+    },
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+    "bytes"
+    "os"
+    "regexp"
+    "strconv"
+    "time"
+
+    "github.com/lucasb-eyer/go-colorful"
+    "github.com/spf13/cobra"
+    "golang.org/x/term"
+)
+
+// terminalQueryTimeout is how long the terminal gets to answer before
+// colorblend carries on without its colors.
+const terminalQueryTimeout = 200 * time.Millisecond
+
+// adaptiveContrast is the contrast ratio --detect-background keeps the
+// gradient at, unless --min-contrast says otherwise: WCAG's minimum for
+// large text.
+const adaptiveContrast = 3
+
+// oscColorReply matches a terminal's answer to an OSC 10 or 11 query,
+// such as ESC ] 11 ; rgb:1e1e/1e1e/2e2e ESC \.
+var oscColorReply = regexp.MustCompile(`\x1b\](1[01]);rgba?:([0-9a-fA-F]{1,4})/([0-9a-fA-F]{1,4})/([0-9a-fA-F]{1,4})`)
+
+// queryTerminalColors asks the terminal for its foreground and background
+// colors with OSC 10 and 11, ending with a device attributes request that
+// every terminal answers, so those ignoring the color queries don't cost
+// the whole timeout.
+func queryTerminalColors() (fg, bg *colorful.Color) {
+    tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+    if err != nil {
+        return nil, nil
+    }
+    defer tty.Close()
+    // Fd would put the tty back into blocking mode, where the read
+    // deadline does nothing
+    conn, err := tty.SyscallConn()
+    if err != nil {
+        return nil, nil
+    }
+    var state *term.State
+    conn.Control(func(fd uintptr) {
+        state, err = term.MakeRaw(int(fd))
+    })
+    if err != nil {
+        return nil, nil
+    }
+    defer conn.Control(func(fd uintptr) {
+        term.Restore(int(fd), state)
+    })
+
+    if _, err := tty.WriteString("\x1b]10;?\x1b\\\x1b]11;?\x1b\\\x1b[c"); err != nil {
+        return nil, nil
+    }
+    if err := tty.SetReadDeadline(time.Now().Add(terminalQueryTimeout)); err != nil {
+        return nil, nil
+    }
+    var reply []byte
+    buf := make([]byte, 256)
+    for {
+        n, err := tty.Read(buf)
+        reply = append(reply, buf[:n]...)
+        // The attributes reply, ESC [ ? ... c, comes last
+        if i := bytes.LastIndex(reply, []byte("\x1b[?")); i >= 0 && bytes.IndexByte(reply[i:], 'c') >= 0 {
+            break
+        }
+        if err != nil {
+            break
+        }
+    }
+
+    for _, match := range oscColorReply.FindAllSubmatch(reply, -1) {
+        c := colorful.Color{R: oscComponent(match[2]), G: oscComponent(match[3]), B: oscComponent(match[4])}
+        if string(match[1]) == "10" {
+            fg = &c
+        } else {
+            bg = &c
+        }
+    }
+    return fg, bg
+}
+
+// oscComponent scales a color component of 1 to 4 hex digits to 0..1.
+func oscComponent(hex []byte) float64 {
+    v, _ := strconv.ParseUint(string(hex), 16, 16)
+    return float64(v) / float64(uint64(1)<<(4*len(hex))-1)
+}
+
+// adaptToTerminal uses the terminal's own colors as the background, picks
+// the dark or light theme to match and keeps the gradient readable on it,
+// leaving alone whatever was set explicitly. Terminals that don't answer
+// change nothing.
+func adaptToTerminal(cmd *cobra.Command) {
+    fg, bg := queryTerminalColors()
+    if bg == nil {
+        return
+    }
+    if !cmd.Flags().Changed("background") {
+        cfg.Background = bg.Hex()
+    }
+    if !cmd.Flags().Changed("theme") {
+        _, _, bgL := bg.Lab()
+        dark := bgL < 0.5
+        if fg != nil {
+            // Light text means a dark theme, whatever the background
+            _, _, fgL := fg.Lab()
+            dark = bgL < fgL
+        }
+        cfg.Theme = "light"
+        if dark {
+            cfg.Theme = "dark"
+        }
+    }
+    if !cmd.Flags().Changed("min-contrast") {
+        cfg.MinContrast = adaptiveContrast
+    }
+}
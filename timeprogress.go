@@ -0,0 +1,90 @@
+package main
+
+import (
+    "math"
+    "regexp"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/ocodo/colorblend/pkg/colorblend"
+)
+
+// defaultTimePattern finds ISO 8601 style timestamps such as
+// 2024-05-01T12:30:00Z or 2024-05-01 12:30:00,123.
+const defaultTimePattern = `\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?:Z|[+-]\d{2}:?\d{2})?`
+
+// timeMatcher is the compiled --time-regex.
+var timeMatcher *regexp.Regexp
+
+// lineTime finds the timestamp of a line with --time-regex, using its
+// first capture group when it has one, and parses it with --time-format.
+func lineTime(line colorblend.Line) (time.Time, bool) {
+    match := timeMatcher.FindStringSubmatch(line.String())
+    if match == nil {
+        return time.Time{}, false
+    }
+    stamp := match[0]
+    if len(match) > 1 {
+        stamp = match[1]
+    }
+    t, err := parseTime(stamp, timeFormat)
+    return t, err == nil
+}
+
+// parseTime parses a timestamp with a Go time layout, "unix" for seconds
+// since the epoch, or for an empty layout the ISO 8601 forms matched by
+// defaultTimePattern.
+func parseTime(stamp, layout string) (time.Time, error) {
+    switch layout {
+    case "unix":
+        seconds, err := strconv.ParseFloat(stamp, 64)
+        if err != nil {
+            return time.Time{}, err
+        }
+        whole, frac := math.Modf(seconds)
+        return time.Unix(int64(whole), int64(frac*1e9)), nil
+    case "":
+        stamp = strings.Replace(strings.Replace(stamp, " ", "T", 1), ",", ".", 1)
+        if t, err := time.Parse(time.RFC3339Nano, stamp); err == nil {
+            return t, nil
+        }
+        return time.Parse("2006-01-02T15:04:05.999999999", stamp)
+    }
+    return time.Parse(layout, stamp)
+}
+
+// timeProgress colors each line by when it was logged, from the earliest
+// timestamp in blocks at the start of the gradient to the latest at the
+// end, so gaps and bursts show as jumps in color. Lines without a
+// timestamp, such as the rest of a stack trace, share the color of the
+// last line that had one.
+func timeProgress(blocks [][]colorblend.Line) func(line colorblend.Line) (float64, bool) {
+    var first, last time.Time
+    for _, block := range blocks {
+        for _, line := range block {
+            t, ok := lineTime(line)
+            if !ok {
+                continue
+            }
+            if first.IsZero() || t.Before(first) {
+                first = t
+            }
+            if last.IsZero() || t.After(last) {
+                last = t
+            }
+        }
+    }
+    span := last.Sub(first)
+
+    progress, seen := 0.0, false
+    return func(line colorblend.Line) (float64, bool) {
+        if t, ok := lineTime(line); ok {
+            progress, seen = 0, true
+            if span > 0 {
+                progress = float64(t.Sub(first)) / float64(span)
+            }
+        }
+        return progress, seen
+    }
+}
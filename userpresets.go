@@ -0,0 +1,195 @@
+package main
+
+import (
+    "fmt"
+    "os"
+    "path/filepath"
+    "regexp"
+    "sort"
+    "strconv"
+    "strings"
+
+    "github.com/spf13/cobra"
+    "github.com/spf13/pflag"
+)
+
+// presetDir holds user presets, one TOML file of flag settings each, next
+// to the config file.
+func presetDir() string {
+    return filepath.Join(filepath.Dir(defaultConfigPath()), "presets")
+}
+
+// validPresetName keeps preset names usable as file names.
+var validPresetName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// unsavedFlags are about a single run rather than a look, so presets
+// never record them.
+var unsavedFlags = map[string]bool{
+    "config": true, "help": true, "version": true, "output": true, "bench": true,
+    "jobs": true, "follow": true, "stream": true, "two-pass": true, "total-chars": true,
+    "list-presets": true,
+}
+
+// userPresetNames lists the saved presets in alphabetical order.
+func userPresetNames() ([]string, error) {
+    entries, err := os.ReadDir(presetDir())
+    if os.IsNotExist(err) {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    var names []string
+    for _, e := range entries {
+        if name, ok := strings.CutSuffix(e.Name(), ".toml"); ok && !e.IsDir() {
+            names = append(names, name)
+        }
+    }
+    sort.Strings(names)
+    return names, nil
+}
+
+// isUserPreset reports whether name is a saved preset rather than a
+// built-in one.
+func isUserPreset(name string) bool {
+    if _, ok := presets[name]; ok || !validPresetName.MatchString(name) {
+        return false
+    }
+    _, err := os.Stat(filepath.Join(presetDir(), name+".toml"))
+    return err == nil
+}
+
+// applyUserPreset applies the settings of a saved --preset as flag
+// defaults, below anything given on the command line. A saved preset
+// may itself name a built-in preset.
+func applyUserPreset(flags *pflag.FlagSet) error {
+    if !isUserPreset(presetName) {
+        return nil
+    }
+    settings, err := loadConfigFile(filepath.Join(presetDir(), presetName+".toml"))
+    if err != nil {
+        return err
+    }
+    presetName = ""
+    for _, s := range settings {
+        if s.key == "preset" {
+            presetName = s.value
+            continue
+        }
+        f := flags.Lookup(s.key)
+        if f == nil || unsavedFlags[s.key] {
+            return fmt.Errorf("line %d: unknown setting %q", s.line, s.key)
+        }
+        if f.Changed {
+            continue
+        }
+        if err := flags.Set(s.key, s.value); err != nil {
+            return fmt.Errorf("line %d: %s: %v", s.line, s.key, err)
+        }
+    }
+    return nil
+}
+
+// savePreset writes every flag set on the command line to a preset file.
+func savePreset(flags *pflag.FlagSet, name string) error {
+    var b strings.Builder
+    fmt.Fprintf(&b, "# colorblend preset %s, written by colorblend preset save\n", name)
+    saved := 0
+    flags.VisitAll(func(f *pflag.Flag) {
+        if !f.Changed || unsavedFlags[f.Name] || flagAliases[f.Name] != "" {
+            return
+        }
+        fmt.Fprintf(&b, "%s = %s\n", f.Name, tomlValue(flags, f))
+        saved++
+    })
+    if saved == 0 {
+        return fmt.Errorf("no flags given to save")
+    }
+    if presetName != "" {
+        if _, ok := presets[presetName]; !ok {
+            return fmt.Errorf("--preset %s: only built-in presets can be saved in a preset", presetName)
+        }
+    }
+
+    if err := os.MkdirAll(presetDir(), 0o755); err != nil {
+        return err
+    }
+    return os.WriteFile(filepath.Join(presetDir(), name+".toml"), []byte(b.String()), 0o644)
+}
+
+// tomlValue formats a flag value the way loadConfigFile reads it back.
+func tomlValue(flags *pflag.FlagSet, f *pflag.Flag) string {
+    switch f.Value.Type() {
+    case "bool", "int", "int64", "float64":
+        return f.Value.String()
+    case "stringSlice":
+        items, _ := flags.GetStringSlice(f.Name)
+        quoted := make([]string, len(items))
+        for i, item := range items {
+            quoted[i] = strconv.Quote(item)
+        }
+        return "[" + strings.Join(quoted, ", ") + "]"
+    }
+    return strconv.Quote(f.Value.String())
+}
+
+var presetCmd = &cobra.Command{
+    Use:   "preset",
+    Short: "Save, list and delete named presets of flags",
+}
+
+var presetSaveCmd = &cobra.Command{
+    Use:   "save NAME [flags]",
+    Short: "Save the given flags as a preset for --preset NAME",
+    Args:  cobra.ExactArgs(1),
+    Run: func(cmd *cobra.Command, args []string) {
+        name := args[0]
+        if !validPresetName.MatchString(name) {
+            usageError(cmd, "Invalid preset name: %s. Use letters, digits, - and _.", name)
+        }
+        if _, ok := presets[name]; ok {
+            usageError(cmd, "%s is a built-in preset; pick another name.", name)
+        }
+        if err := savePreset(cmd.Flags(), name); err != nil {
+            runtimeError(err)
+        }
+    },
+}
+
+var presetListCmd = &cobra.Command{
+    Use:   "list",
+    Short: "List the built-in and saved presets",
+    Args:  cobra.NoArgs,
+    Run: func(cmd *cobra.Command, args []string) {
+        listPresets()
+        names, err := userPresetNames()
+        if err != nil {
+            runtimeError(err)
+        }
+        for _, name := range names {
+            settings, err := loadConfigFile(filepath.Join(presetDir(), name+".toml"))
+            if err != nil {
+                runtimeError(err)
+            }
+            parts := make([]string, len(settings))
+            for i, s := range settings {
+                parts[i] = "--" + s.key + "=" + s.value
+            }
+            fmt.Printf("%-10s %s\n", name, strings.Join(parts, " "))
+        }
+    },
+}
+
+var presetDeleteCmd = &cobra.Command{
+    Use:   "delete NAME",
+    Short: "Delete a saved preset",
+    Args:  cobra.ExactArgs(1),
+    Run: func(cmd *cobra.Command, args []string) {
+        if !isUserPreset(args[0]) {
+            usageError(cmd, "No saved preset named %s.", args[0])
+        }
+        if err := os.Remove(filepath.Join(presetDir(), args[0]+".toml")); err != nil {
+            runtimeError(err)
+        }
+    },
+}
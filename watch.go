@@ -0,0 +1,104 @@
+package main
+
+import (
+    "bufio"
+    "bytes"
+    "errors"
+    "fmt"
+    "os"
+    "os/exec"
+    "os/signal"
+    "time"
+
+    "github.com/ocodo/colorblend/pkg/colorblend"
+    "github.com/spf13/cobra"
+    "golang.org/x/term"
+)
+
+var watchInterval float64
+
+var watchCmd = &cobra.Command{
+    Use:   "watch [flags] [--] command [arg...]",
+    Short: "Run a command every few seconds and show its output colored",
+    Args:  cobra.MinimumNArgs(1),
+    Run: func(cmd *cobra.Command, args []string) {
+        configure(cmd, args)
+        if watchInterval <= 0 {
+            usageError(cmd, "--interval must be greater than 0.")
+        }
+        if format != "ansi" {
+            usageError(cmd, "watch only writes --format ansi.")
+        }
+
+        cfg.Color = colorEnabled(colorMode)
+        if detectBackground && cfg.Color {
+            adaptToTerminal(cmd)
+        }
+        stdout = bufio.NewWriter(os.Stdout)
+        out, err := colorblend.NewRenderer(format, stdout, &cfg)
+        if err != nil {
+            runtimeError(err)
+        }
+        interval := time.Duration(watchInterval * float64(time.Second))
+        if err := watch(out, args, interval); err != nil {
+            runtimeError(err)
+        }
+    },
+}
+
+// watch runs command every interval until interrupted, redrawing the
+// screen with its colored output each time. Like watch(1), it keeps
+// going when the command fails, and shows only as much of the output as
+// fits the terminal.
+func watch(out colorblend.Renderer, command []string, interval time.Duration) error {
+    interrupt := make(chan os.Signal, 1)
+    signal.Notify(interrupt, os.Interrupt)
+    defer signal.Stop(interrupt)
+
+    // Draw on the alternate screen without a cursor, leaving the
+    // terminal as it was afterwards. Output that isn't bound for a
+    // terminal gets every run one after another instead.
+    screen := term.IsTerminal(int(os.Stdout.Fd()))
+    if screen {
+        fmt.Fprint(stdout, "\x1b[?1049h\x1b[?25l")
+        defer func() {
+            fmt.Fprint(stdout, "\x1b[?25h\x1b[?1049l")
+            stdout.Flush()
+        }()
+    }
+
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+    for {
+        output, err := exec.Command(command[0], command[1:]...).CombinedOutput()
+        var exit *exec.ExitError
+        if err != nil && !errors.As(err, &exit) {
+            return err
+        }
+        lines, err := readLines(bytes.NewReader(output))
+        if err != nil {
+            return err
+        }
+
+        if screen {
+            // The last row stays free so the final line break doesn't
+            // scroll the top of the output away
+            if _, height, err := term.GetSize(int(os.Stdout.Fd())); err == nil && height > 1 && len(lines) >= height {
+                lines = lines[:height-1]
+            }
+            fmt.Fprint(stdout, "\x1b[H\x1b[2J")
+        }
+        if err := colorblend.PaintLines(&cfg, out, lines); err != nil {
+            return err
+        }
+        if err := finish(out); err != nil {
+            return err
+        }
+
+        select {
+        case <-ticker.C:
+        case <-interrupt:
+            return nil
+        }
+    }
+}